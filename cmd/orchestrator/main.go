@@ -2,76 +2,256 @@
 // Main entry point for the Go orchestrator that manages Python transcription workers.
 package main
 
+//go:generate go run ../../internal/config/envgen -out ../../.env.example
+//go:generate go run ../../internal/config/envgen -format yaml -out ../../config.example.yaml
+
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"os"
 	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"whisper-local/internal/config"
+	"whisper-local/internal/health"
+	"whisper-local/internal/logging"
+	"whisper-local/internal/metrics"
 	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/telemetry"
+	"whisper-local/internal/validator"
 	"whisper-local/internal/worker"
 )
 
 func main() {
-	log.SetFlags(log.Ltime | log.Lmsgprefix)
-	log.Println("🚀 Whisper-Local starting...")
+	slog.Info("🚀 Whisper-Local starting")
 
 	godotenv.Load() // Ignore error, ENV vars take precedence
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("❌ Config error: %v", err)
+		var invalid config.ValidationErrors
+		if errors.As(err, &invalid) {
+			for _, fieldErr := range invalid {
+				slog.Error("❌ Invalid config",
+					slog.String("field", fieldErr.Field),
+					slog.Any("value", fieldErr.Value),
+					slog.String("reason", fieldErr.Reason))
+			}
+			os.Exit(1)
+		}
+		fatal("❌ Config error", err)
 	}
-	log.Printf("⚙️  Config: %d workers, model=%s (%s)",
-		cfg.MaxWorkers, cfg.WhisperModel, cfg.WhisperDevice)
 
-	// Connect to RabbitMQ
-	conn, err := rabbitmq.Connect(cfg.RabbitMQURL)
-	if err != nil {
-		log.Fatalf("❌ RabbitMQ: %v", err)
+	// Install the structured logger; stdlib log calls are routed through it
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fatal("❌ Logging", err)
 	}
-	defer conn.Close()
 
-	// Create consumer and producer
-	consumer, err := rabbitmq.NewConsumer(conn, cfg.MaxWorkers)
+	slog.Info("⚙️  Config loaded",
+		slog.Int("workers", cfg.MaxWorkers),
+		slog.String("model", cfg.WhisperModel),
+		slog.String("device", cfg.WhisperDevice))
+	if cfg.Profile != "" {
+		slog.Info("🧩 Config profile applied", slog.String("profile", cfg.Profile))
+	}
+	if cfg.AutoWorkerCount {
+		slog.Info("🧮 Worker count derived from the CPU limit", slog.Int("workers", cfg.MaxWorkers))
+	} else if cfg.WorkerCountStrategy != config.WorkerCountFixed {
+		slog.Info("🧮 Worker count derived from the CPU count",
+			slog.String("strategy", cfg.WorkerCountStrategy),
+			slog.Int("cpus", runtime.NumCPU()),
+			slog.Int("workers", cfg.MaxWorkers))
+	}
+	for model, workers := range cfg.ModelPools {
+		slog.Info("⚙️  Model pool configured", slog.Int("workers", workers), slog.String("model", model))
+	}
+
+	validator.FfprobePath = cfg.FfprobePath
+	if len(cfg.AllowedAudioDirs) == 0 {
+		slog.Warn("⚠️  ALLOWED_AUDIO_DIRS is empty, any audio path is accepted")
+	}
+
+	// Only warm the model cache: start every Python process, wait for READY
+	// and exit without connecting to RabbitMQ
+	if cfg.PreloadOnly {
+		processPools, err := newProcessPools(cfg)
+		if err != nil {
+			fatal("❌ Python pool", err)
+		}
+		for _, processPool := range processPools {
+			processPool.Shutdown()
+		}
+		slog.Info("✅ Models preloaded, exiting")
+		return
+	}
+
+	// Export job spans; trace context is propagated even when disabled
+	stopTracing := telemetry.Setup(cfg.OTLPEndpoint, cfg.OTelServiceName)
+	defer stopTracing(context.Background())
+
+	// Expose Prometheus metrics
+	if cfg.MetricsEnabled {
+		metricsServer := metrics.Serve(cfg.MetricsPort)
+		defer metricsServer.Close()
+	}
+
+	// Connect to RabbitMQ (re-dials automatically if the broker drops us)
+	conn, err := rabbitmq.NewManagedConnection(cfg.RabbitMQURL, cfg.AMQP, rabbitmq.ReconnectConfig{
+		InitialInterval: cfg.RabbitMQReconnectInitialInterval,
+		MaxInterval:     cfg.RabbitMQReconnectMaxInterval,
+		Multiplier:      2,
+		MaxRetries:      cfg.RabbitMQMaxRetries,
+		RetryInterval:   cfg.RabbitMQRetryInterval,
+	})
 	if err != nil {
-		log.Fatalf("❌ Consumer: %v", err)
+		fatal("❌ RabbitMQ", err)
 	}
-	defer consumer.Close()
+	defer conn.Close()
 
-	producer, err := rabbitmq.NewProducer(conn, cfg.WhisperModel)
+	// Create producer and consumer; the consumer archives oversized
+	// messages through the producer
+	producer, err := rabbitmq.NewProducer(conn, rabbitmq.ProducerOptions{
+		Model: cfg.WhisperModel,
+		Retry: rabbitmq.RetryPolicy{
+			BaseDelayMs: cfg.RetryBaseDelayMs,
+			MaxDelayMs:  cfg.RetryMaxDelayMs,
+			JitterPct:   cfg.RetryJitterPct,
+		},
+		ConfirmTimeout: cfg.PublishConfirmTimeout,
+		ExchangeType:   cfg.ExchangeType,
+
+		MaxMessageSize:     cfg.MaxMessageSizeBytes,
+		TruncateOnOversize: cfg.TruncateOnOversize,
+		ResultTTLMs:        cfg.ResultTTLMs,
+		HeaderExchange:     cfg.HeaderExchangeMode,
+		AuditExchange:      cfg.AuditExchange,
+	})
 	if err != nil {
-		log.Fatalf("❌ Producer: %v", err)
+		fatal("❌ Producer", err)
 	}
 	defer producer.Close()
 
-	// Initialize Python workers
-	processPool, err := worker.NewProcessPool(cfg)
+	// An explicit job buffer is prefetched on top of one message per worker
+	// so it can actually fill
+	prefetch := cfg.TotalWorkers()
+	if cfg.JobChannelBuffer > 0 {
+		prefetch += cfg.JobChannelBuffer
+	}
+	consumer, err := newConsumer(conn, cfg, prefetch, producer)
 	if err != nil {
-		log.Fatalf("❌ Python pool: %v", err)
+		fatal("❌ Consumer", err)
 	}
-	defer processPool.Shutdown()
+	defer consumer.Close()
 
-	// Start worker pool
-	workerPool := worker.NewPool(processPool, producer, cfg.MaxWorkers)
+	// Initialize Python workers; a dry run answers jobs without them
+	executors := make(map[string]worker.Executor)
+	if cfg.DryRun {
+		slog.Warn("🧪 DRY_RUN is set, jobs are validated and answered without Python")
+		executors[worker.DefaultPool] = worker.NewDryRunExecutor(cfg.TotalWorkers())
+	} else {
+		processPools, err := newProcessPools(cfg)
+		if err != nil {
+			fatal("❌ Python pool", err)
+		}
+		for model, processPool := range processPools {
+			executors[model] = processPool
+		}
+	}
+
+	// Start worker pool (shuts down all process pools on exit)
+	workerPool := worker.NewPool(executors, producer, worker.PoolOptions{
+		NumWorkers:    cfg.TotalWorkers(),
+		JobBuffer:     cfg.JobChannelBuffer,
+		JobTimeout:    cfg.JobTimeout,
+		MaxFileSizeMB: cfg.MaxFileSizeMB,
+		MaxDuration:   time.Duration(cfg.MaxAudioDurationSec) * time.Second,
+		AllowedDirs:   cfg.AllowedAudioDirs,
+		Validator:     validator.NewValidator(cfg),
+		AllowedModels: cfg.ModelAllowlist(),
+		SampleRate:    cfg.AudioSampleRate,
+		RecentJobs:    cfg.RecentJobsSize,
+		JobsPerSecond: cfg.JobsPerSecond,
+		MaxQueueAge:   cfg.MaxJobQueueAge,
+		DryRun:        cfg.DryRun,
+
+		CallbackTimeout:        cfg.CallbackTimeout,
+		MaxCallbackConcurrency: cfg.MaxCallbackConcurrency,
+
+		PauseWarnAfter: cfg.PauseWarnAfter,
+
+		HeartbeatInterval: cfg.HeartbeatInterval,
+		HeartbeatTimeout:  cfg.HeartbeatTimeout,
+	})
 	workerPool.Start()
 	defer workerPool.Shutdown()
+	pauseWhileThrottled(consumer, workerPool)
+
+	if cfg.MetricsEnabled {
+		registerPoolMetrics(workerPool)
+		registerConsumerMetrics(consumer)
+		if cfg.RabbitMQManagementURL != "" {
+			registerLagMetric(consumer)
+		}
+	}
+
+	// Start consuming; cancelled on shutdown so undelivered messages are requeued
+	consumeCtx, stopConsuming := context.WithCancel(context.Background())
+	defer stopConsuming()
+	jobs, err := consumer.ConsumeWithContext(consumeCtx)
+	if err != nil {
+		fatal("❌ Consume", err)
+	}
 
-	// Start consuming
-	jobs, err := consumer.Consume()
+	// Dead letters can be sent back to the main queue from the admin API
+	dlq, err := rabbitmq.NewDLQConsumer(conn)
 	if err != nil {
-		log.Fatalf("❌ Consume: %v", err)
+		fatal("❌ DLQ", err)
+	}
+	defer dlq.Close()
+	dlq.WithExchangeType(cfg.ExchangeType)
+
+	// Start health probes
+	healthServer := health.NewServer(workerPool, conn, consumer, cfg)
+	healthServer.EnableAdmin()
+	healthServer.WithDLQ(dlq)
+	healthServer.WithTopologyCheck(rabbitmq.NewHealthChecker(conn, consumedQueues(cfg), cfg.HeaderExchangeMode, cfg.AuditExchange))
+	healthServer.Start()
+	defer healthServer.Close()
+
+	// Apply live configuration changes from the .env file
+	if cfg.ReloadInterval > 0 {
+		reloadCtx, stopReload := context.WithCancel(context.Background())
+		defer stopReload()
+		go applyConfigUpdates(cfg.Watch(reloadCtx, cfg.ReloadInterval), cfg, workerPool, producer)
+	}
+
+	// SIGHUP restarts the default Python pool without dropping RabbitMQ
+	if !cfg.DryRun {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+		go func() {
+			for range hangup {
+				restartProcessPool(workerPool, producer)
+			}
+		}()
 	}
 
 	// Setup graceful shutdown
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
-	log.Println("✅ Ready, waiting for jobs...")
+	slog.Info("✅ Ready, waiting for jobs")
 
 	// Main loop
 	go func() {
@@ -82,5 +262,259 @@ func main() {
 
 	// Wait for shutdown signal
 	<-shutdown
-	log.Println("\n🛑 Shutting down...")
+	slog.Info("🛑 Shutting down")
+	stopConsuming()
+
+	// Let in-flight jobs publish their results before workers stop
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := workerPool.Drain(drainCtx); err != nil {
+		slog.Warn("⚠️  Drain incomplete",
+			slog.Duration("timeout", cfg.ShutdownTimeout),
+			slog.Any("error", err))
+	}
+}
+
+// fatal logs err with msg and exits without running deferred calls.
+func fatal(msg string, err error, attrs ...any) {
+	slog.Error(msg, append([]any{slog.Any("error", err)}, attrs...)...)
+	os.Exit(1)
+}
+
+// registerPoolMetrics exposes worker pool statistics read at scrape time.
+func registerPoolMetrics(workerPool *worker.Pool) {
+	metrics.NewGaugeFunc("whisper_jobs_processing",
+		"Jobs currently being processed.",
+		func() float64 { return float64(workerPool.Stats().JobsProcessing) })
+	metrics.NewGaugeFunc("whisper_workers",
+		"Worker goroutines in the pool.",
+		func() float64 { return float64(workerPool.NumWorkers()) })
+	metrics.NewGaugeFunc("whisper_uptime_seconds",
+		"Seconds since the worker pool was created.",
+		func() float64 { return workerPool.Stats().UptimeSeconds })
+}
+
+// pauseWhileThrottled pauses pool while the consumer rate limit holds jobs
+// back and resumes it once the limit is released. Only a pause started here
+// is lifted here; a pause requested through the admin API stays in place.
+func pauseWhileThrottled(consumer rabbitmq.JobConsumer, pool *worker.Pool) {
+	var paused atomic.Bool
+	consumer.OnThrottle(func(throttled bool) {
+		if throttled {
+			if pool.Pause() {
+				paused.Store(true)
+			}
+		} else if paused.Swap(false) {
+			pool.Resume()
+		}
+	})
+}
+
+// registerConsumerMetrics exposes consumer counters read at scrape time.
+func registerConsumerMetrics(consumer rabbitmq.JobConsumer) {
+	metrics.NewGaugeFunc("whisper_duplicates_skipped",
+		"Redelivered messages ACKed without processing because they were already ACKed.",
+		func() float64 { return float64(consumer.Stats().DuplicatesSkipped) })
+	metrics.NewGaugeFunc("whisper_consumer_throttled",
+		"1 while CONSUMER_RATE_LIMIT_RPS is holding jobs back, 0 otherwise.",
+		func() float64 {
+			if consumer.Throttled() {
+				return 1
+			}
+			return 0
+		})
+	metrics.NewGaugeFunc("whisper_oversized_messages_dropped",
+		"Inbound messages dead-lettered because their body exceeded MAX_INBOUND_MESSAGE_SIZE_BYTES.",
+		func() float64 { return float64(consumer.Stats().OversizedMessagesDropped) })
+}
+
+// registerLagMetric exposes the consumed queue depth reported by the
+// RabbitMQ management API, NaN while it cannot be read.
+func registerLagMetric(consumer rabbitmq.JobConsumer) {
+	metrics.NewGaugeFunc("whisper_queue_lag",
+		"Messages ready in the consumed queues, from the RabbitMQ management API.",
+		func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			lag, err := consumer.Lag(ctx)
+			if err != nil {
+				slog.Warn("⚠️  Queue lag unavailable", slog.Any("error", err))
+				return math.NaN()
+			}
+			return float64(lag)
+		})
+}
+
+// processPoolMu serializes model hot swaps and SIGHUP restarts of the
+// default process pool.
+var processPoolMu sync.Mutex
+
+// restartProcessPool re-reads the configuration, starts a new default process
+// pool from it and swaps it into workerPool. New jobs use the new pool once
+// its processes are READY; the old pool is shut down after its in-flight
+// jobs finish, or after JOB_TIMEOUT_SEC. Only the default pool, its worker
+// count and the reported model are updated; other changes need a restart.
+func restartProcessPool(workerPool *worker.Pool, producer *rabbitmq.Producer) {
+	processPoolMu.Lock()
+	defer processPoolMu.Unlock()
+
+	slog.Info("🔁 SIGHUP received, restarting Python workers")
+	next, err := config.Reload()
+	if err != nil {
+		slog.Error("❌ Restart aborted, keeping the current workers", slog.Any("error", err))
+		return
+	}
+	newPool, err := worker.NewProcessPool(next)
+	if err != nil {
+		slog.Error("❌ Restart aborted, keeping the current workers", slog.Any("error", err))
+		return
+	}
+
+	old := workerPool.SwapProcessPool(newPool)
+	producer.SetModel(next.WhisperModel)
+	if err := workerPool.Resize(next.MaxWorkers); err != nil {
+		slog.Error("❌ Resize failed", slog.Any("error", err))
+	}
+
+	if oldPool, ok := old.(*worker.ProcessPool); ok {
+		drainTimeout := next.JobTimeout
+		if drainTimeout <= 0 {
+			drainTimeout = time.Hour
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		if err := oldPool.WaitForIdle(ctx); err != nil {
+			slog.Warn("⚠️  Old Python workers still busy, stopping them", slog.Any("error", err))
+		}
+		cancel()
+		oldPool.Shutdown()
+	}
+
+	slog.Info("✅ Python workers restarted",
+		slog.String("model", next.WhisperModel),
+		slog.Int("workers", next.MaxWorkers))
+}
+
+// applyConfigUpdates applies the live-reloadable settings of each new Config
+// and warns about changes that need a restart.
+func applyConfigUpdates(updates <-chan *config.Config, current *config.Config, workerPool *worker.Pool, producer *rabbitmq.Producer) {
+	for next := range updates {
+		for _, key := range current.Diff(next) {
+			if config.RequiresRestart(key) {
+				slog.Warn("⚠️  Config change requires restart", slog.String("key", key))
+				continue
+			}
+
+			slog.Info("🔧 Applying config change", slog.String("key", key))
+			switch key {
+			case "WORKERS_COUNT":
+				if err := workerPool.Resize(next.MaxWorkers); err != nil {
+					slog.Error("❌ Resize failed", slog.Any("error", err))
+				}
+			case "WHISPER_MODEL":
+				// A busy process may need a full job before it can be swapped.
+				// A dry run has no processes to swap
+				swapTimeout := next.JobTimeout
+				if swapTimeout <= 0 {
+					swapTimeout = time.Hour
+				}
+				processPoolMu.Lock()
+				if processPool, ok := workerPool.DefaultExecutor().(*worker.ProcessPool); ok {
+					if err := processPool.HotSwapModel(next.WhisperModel, swapTimeout); err != nil {
+						slog.Error("❌ Model swap failed", slog.Any("error", err))
+					}
+				}
+				processPoolMu.Unlock()
+				producer.SetModel(next.WhisperModel)
+			case "LOG_LEVEL":
+				logging.SetLevel(next.LogLevel)
+			}
+		}
+		current = next
+	}
+}
+
+// newConsumer creates the job consumer: a MultiConsumer when CONSUMER_QUEUES
+// is set, otherwise a Consumer of CONSUMER_QUEUE. Oversized messages are
+// archived through deadLetters.
+func newConsumer(conn rabbitmq.ChannelSource, cfg *config.Config, prefetch int, deadLetters *rabbitmq.Producer) (rabbitmq.JobConsumer, error) {
+	prefetchConfig := rabbitmq.PrefetchConfig{
+		GlobalPrefetch:     prefetch,
+		PerPriorityBuckets: cfg.PriorityPrefetchBuckets,
+	}
+	topology := rabbitmq.Topology{
+		ExchangeType: cfg.ExchangeType,
+		Queue:        cfg.ConsumerQueue,
+		BindingKey:   cfg.TopicBindingKey,
+	}
+	var filter func(rabbitmq.TranscriptionRequest) bool
+	if len(cfg.BlockedAttachmentIDs) > 0 {
+		filter = rabbitmq.BlocklistFilter(cfg.BlockedAttachmentIDs)
+	}
+
+	if len(cfg.ConsumerQueues) == 0 {
+		consumer, err := rabbitmq.NewConsumer(conn, prefetchConfig, topology)
+		if err != nil {
+			return nil, err
+		}
+		return consumer.WithRateLimit(cfg.ConsumerRateLimitRPS).
+			WithDefaultPriority(cfg.DefaultJobPriority).
+			WithTagPrefix(cfg.ConsumerTagPrefix).
+			WithMaxJobAgeWarn(cfg.MaxJobAgeWarn).
+			WithFilter(filter).
+			WithDeduplication(cfg.DedupCacheSize, cfg.DedupTTL).
+			WithMaxMessageSize(cfg.MaxInboundMessageSizeBytes, deadLetters).
+			WithManagementAPI(cfg.RabbitMQManagementURL, cfg.Vhost(), cfg.LagCacheTTL), nil
+	}
+
+	queues := make([]rabbitmq.QueueConfig, 0, len(cfg.ConsumerQueues))
+	for _, queue := range cfg.ConsumerQueues {
+		queues = append(queues, rabbitmq.QueueConfig{Name: queue.Name, RoutingKey: queue.RoutingKey, Weight: queue.Weight})
+	}
+	consumer, err := rabbitmq.NewMultiConsumer(conn, prefetchConfig, topology, queues)
+	if err != nil {
+		return nil, err
+	}
+	return consumer.WithRateLimit(cfg.ConsumerRateLimitRPS).
+		WithDefaultPriority(cfg.DefaultJobPriority).
+		WithTagPrefix(cfg.ConsumerTagPrefix).
+		WithMaxJobAgeWarn(cfg.MaxJobAgeWarn).
+		WithFilter(filter).
+		WithDeduplication(cfg.DedupCacheSize, cfg.DedupTTL).
+		WithMaxMessageSize(cfg.MaxInboundMessageSizeBytes, deadLetters).
+		WithManagementAPI(cfg.RabbitMQManagementURL, cfg.Vhost(), cfg.LagCacheTTL), nil
+}
+
+// consumedQueues returns the names of the queues the consumer reads.
+func consumedQueues(cfg *config.Config) []string {
+	if len(cfg.ConsumerQueues) == 0 {
+		return []string{cfg.ConsumerQueue}
+	}
+	names := make([]string, len(cfg.ConsumerQueues))
+	for i, queue := range cfg.ConsumerQueues {
+		names[i] = queue.Name
+	}
+	return names
+}
+
+// newProcessPools starts the default process pool and one per
+// WHISPER_MODEL_POOLS entry, keyed as worker.NewPool expects. It returns
+// once every process is READY.
+func newProcessPools(cfg *config.Config) (map[string]*worker.ProcessPool, error) {
+	processPool, err := worker.NewProcessPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	processPools := map[string]*worker.ProcessPool{worker.DefaultPool: processPool}
+
+	for model, workers := range cfg.ModelPools {
+		modelPool, err := worker.NewProcessPool(cfg.ForModel(model, workers))
+		if err != nil {
+			for _, started := range processPools {
+				started.Shutdown()
+			}
+			return nil, fmt.Errorf("failed to start the %s pool: %w", model, err)
+		}
+		processPools[model] = modelPool
+	}
+	return processPools, nil
 }
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// buildAMQPConfig returns the dial settings for RabbitMQ: connect timeout,
+// heartbeat, vhost and, for amqps:// URLs, the client certificate and CA
+// bundle.
+func buildAMQPConfig(c *Config) (amqp.Config, error) {
+	amqpCfg := amqp.Config{
+		Heartbeat: time.Duration(c.RabbitMQHeartbeatSec) * time.Second,
+		Vhost:     c.RabbitMQVhost,
+		// Bounds the TCP connect and the TLS and AMQP handshakes of each attempt
+		Dial: amqp.DefaultDial(c.RabbitMQConnectTimeout),
+	}
+
+	if c.RabbitMQTLSCertFile == "" && c.RabbitMQTLSKeyFile == "" && c.RabbitMQTLSCAFile == "" {
+		return amqpCfg, nil
+	}
+
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.RabbitMQTLSCertFile != "" && c.RabbitMQTLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.RabbitMQTLSCertFile, c.RabbitMQTLSKeyFile)
+		if err != nil {
+			return amqp.Config{}, fmt.Errorf("failed to load RabbitMQ client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if c.RabbitMQTLSCAFile != "" {
+		pem, err := os.ReadFile(c.RabbitMQTLSCAFile)
+		if err != nil {
+			return amqp.Config{}, fmt.Errorf("failed to read RabbitMQ CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return amqp.Config{}, fmt.Errorf("no certificates found in RabbitMQ CA file %s", c.RabbitMQTLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	amqpCfg.TLSClientConfig = tlsCfg
+
+	return amqpCfg, nil
+}
+
+// Vhost returns the RabbitMQ virtual host: RABBITMQ_VHOST, or the one in
+// RABBITMQ_URL, which defaults to "/".
+func (c *Config) Vhost() string {
+	if c.RabbitMQVhost != "" {
+		return c.RabbitMQVhost
+	}
+	uri, err := amqp.ParseURI(c.RabbitMQURL)
+	if err != nil {
+		return "/"
+	}
+	return uri.Vhost
+}
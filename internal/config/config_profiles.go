@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// profiles are the presets selected with WHISPER_PROFILE. Each one replaces
+// the defaults of a few variables for a common deployment; variables set in
+// the environment or the config file still take precedence.
+//
+//   - cpu-small: one process with the base model in int8, for laptops and
+//     small VMs.
+//   - cpu-large: four processes with the medium model, for a dedicated
+//     multi-core host.
+//   - gpu-single: two processes with large-v2 on one CUDA GPU.
+//   - gpu-multi: eight processes with large-v3 spread over four CUDA GPUs,
+//     two per GPU.
+var profiles = map[string]map[string]string{
+	"cpu-small": {
+		"WORKERS_COUNT":        "1",
+		"WHISPER_MODEL":        "base",
+		"WHISPER_DEVICE":       "cpu",
+		"WHISPER_COMPUTE_TYPE": "int8",
+	},
+	"cpu-large": {
+		"WORKERS_COUNT":        "4",
+		"WHISPER_MODEL":        "medium",
+		"WHISPER_DEVICE":       "cpu",
+		"WHISPER_COMPUTE_TYPE": "int8",
+	},
+	"gpu-single": {
+		"WORKERS_COUNT":        "2",
+		"WHISPER_MODEL":        "large-v2",
+		"WHISPER_DEVICE":       "cuda",
+		"WHISPER_COMPUTE_TYPE": "float16",
+	},
+	"gpu-multi": {
+		"WORKERS_COUNT":        "8",
+		"WHISPER_MODEL":        "large-v3",
+		"WHISPER_DEVICE":       "cuda",
+		"WHISPER_COMPUTE_TYPE": "float16",
+		"GPU_DEVICES":          "cuda:0,cuda:1,cuda:2,cuda:3",
+	},
+}
+
+// ProfileNames returns the names accepted by WHISPER_PROFILE, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile makes the defaults of profile the fallback of src for the
+// variables it sets, and records it in cfg. An empty profile applies none.
+// It must run before any other variable is read.
+func applyProfile(profile string, src *source, cfg *Config) error {
+	if profile == "" {
+		return nil
+	}
+	values, ok := profiles[profile]
+	if !ok {
+		return fmt.Errorf("invalid WHISPER_PROFILE %q: must be one of %s", profile, strings.Join(ProfileNames(), ", "))
+	}
+	src.profile = values
+	cfg.Profile = profile
+	return nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DownwardCPULimitPath is where the pod spec mounts the container CPU limit
+// through the Kubernetes Downward API (resourceFieldRef limits.cpu).
+const DownwardCPULimitPath = "/etc/podinfo/cpu_limit"
+
+// autoWorkerCount sizes the default pool from the CPU limit in path: one
+// worker per fraction CPUs, at least 1 and at most hardLimit.
+func autoWorkerCount(path string, fraction float64, hardLimit int) (int, error) {
+	if fraction <= 0 {
+		return 0, fmt.Errorf("invalid WORKER_CPU_FRACTION: must be greater than 0")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CPU limit: %w", err)
+	}
+	cpus, err := parseCPUQuantity(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid CPU limit in %s: %w", path, err)
+	}
+
+	workers := int(math.Floor(cpus / fraction))
+	if workers < 1 {
+		workers = 1
+	}
+	if hardLimit > 0 && workers > hardLimit {
+		workers = hardLimit
+	}
+	return workers, nil
+}
+
+// parseCPUQuantity parses a CPU amount in cores ("2", "1.5") or millicores
+// ("500m"). The Downward API writes whole units of the resourceFieldRef
+// divisor, so the field must keep the default divisor of 1: with 1m the
+// file holds millicores without the suffix and would be read as cores.
+func parseCPUQuantity(value string) (float64, error) {
+	millis, isMillis := strings.CutSuffix(value, "m")
+	if isMillis {
+		n, err := strconv.ParseFloat(millis, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("expected a positive quantity, got %q", value)
+		}
+		return n / 1000, nil
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive quantity, got %q", value)
+	}
+	return n, nil
+}
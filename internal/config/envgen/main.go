@@ -0,0 +1,74 @@
+// Command envgen writes a .env.example file listing every supported
+// environment variable with its default value, or a documented
+// config.example.yaml with the same keys.
+//
+// Usage (from cmd/orchestrator): go generate
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"whisper-local/internal/config"
+)
+
+func main() {
+	out := flag.String("out", ".env.example", "output file")
+	format := flag.String("format", "env", "output format: env or yaml")
+	flag.Parse()
+
+	var buf bytes.Buffer
+	switch *format {
+	case "env":
+		writeEnv(&buf)
+	case "yaml":
+		writeYAML(&buf)
+	default:
+		log.Fatalf("envgen: unknown format %q", *format)
+	}
+
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("envgen: %v", err)
+	}
+}
+
+// writeEnv writes KEY=default lines grouped by section.
+func writeEnv(buf *bytes.Buffer) {
+	defaults := config.GetDefaults()
+
+	buf.WriteString("# Generated by internal/config/envgen. DO NOT EDIT.\n")
+
+	group := ""
+	for _, key := range config.GetAllEnvKeys() {
+		if g := config.GetEnvGroup(key); g != group {
+			group = g
+			fmt.Fprintf(buf, "\n# %s Configuration\n", group)
+		}
+		fmt.Fprintf(buf, "%s=%s\n", key, defaults[key])
+	}
+}
+
+// writeYAML writes a flat YAML config file with every key documented.
+// WHISPER_CONFIG_FILE is skipped since it can only be set in the environment.
+func writeYAML(buf *bytes.Buffer) {
+	defaults := config.GetDefaults()
+
+	buf.WriteString("# Generated by internal/config/envgen. DO NOT EDIT.\n")
+	buf.WriteString("# Load with WHISPER_CONFIG_FILE=config.yaml; environment variables override these values.\n")
+
+	group := ""
+	for _, key := range config.GetAllEnvKeys() {
+		if key == "WHISPER_CONFIG_FILE" {
+			continue
+		}
+		if g := config.GetEnvGroup(key); g != group {
+			group = g
+			fmt.Fprintf(buf, "\n# %s Configuration\n", group)
+		}
+		fmt.Fprintf(buf, "# %s\n%s: %s\n", config.GetEnvHelp(key), key, strconv.Quote(defaults[key]))
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readConfigFile parses a config file into registered variable values.
+// Only flat files are supported: one scalar per key, keys named like the
+// environment variables (case-insensitive). TOML [section] headers are
+// ignored so keys can be grouped.
+func readConfigFile(path string) (map[string]string, error) {
+	var separator string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		separator = ":"
+	case ".toml":
+		separator = "="
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (want .yaml or .toml)", ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		key, value, ok, err := parseConfigLine(scanner.Text(), separator)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, registered := defaults[key]; !registered || key == "WHISPER_CONFIG_FILE" {
+			return nil, fmt.Errorf("%s:%d: unknown key %q", path, lineNo, key)
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("%s:%d: duplicate key %q", path, lineNo, key)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return values, nil
+}
+
+// parseConfigLine splits a "key<separator>value" line. It returns ok=false
+// for blank lines, comments, YAML document markers and TOML section headers.
+func parseConfigLine(line, separator string) (key, value string, ok bool, err error) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "", strings.HasPrefix(trimmed, "#"), trimmed == "---":
+		return "", "", false, nil
+	case separator == "=" && strings.HasPrefix(trimmed, "["):
+		return "", "", false, nil
+	case separator == ":" && strings.TrimLeft(line, " \t") != line:
+		return "", "", false, fmt.Errorf("nested values are not supported")
+	case strings.HasPrefix(trimmed, "- "):
+		return "", "", false, fmt.Errorf("lists are not supported")
+	}
+
+	rawKey, rawValue, found := strings.Cut(trimmed, separator)
+	if !found {
+		return "", "", false, fmt.Errorf("expected key%svalue", separator)
+	}
+	key = strings.ToUpper(strings.TrimSpace(rawKey))
+	if key == "" {
+		return "", "", false, fmt.Errorf("empty key")
+	}
+
+	value, err = parseConfigValue(strings.TrimSpace(rawValue))
+	if err != nil {
+		return "", "", false, fmt.Errorf("%s: %w", key, err)
+	}
+	return key, value, true, nil
+}
+
+// parseConfigValue unquotes a scalar and drops a trailing comment.
+func parseConfigValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '"':
+		quoted, err := strconv.QuotedPrefix(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string")
+		}
+		if err := checkTrailing(raw[len(quoted):]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(quoted)
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if err := checkTrailing(raw[end+2:]); err != nil {
+			return "", err
+		}
+		return raw[1 : end+1], nil
+	}
+
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// checkTrailing accepts only whitespace or a comment after a quoted string.
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after string", rest)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"whisper-local/internal/logging"
+)
+
+// FieldError describes a single invalid configuration value.
+type FieldError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s=%v: %s", e.Field, e.Value, e.Reason)
+}
+
+// ValidationErrors collects every invalid field found by Validate.
+type ValidationErrors []FieldError
+
+// Error implements the error interface, listing all violations.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(msgs, "; "))
+}
+
+// Validate checks that configuration values are usable.
+// It reports every violation at once as ValidationErrors.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	add := func(field string, value interface{}, reason string) {
+		errs = append(errs, FieldError{Field: field, Value: value, Reason: reason})
+	}
+
+	if !strings.HasPrefix(c.RabbitMQURL, "amqp://") && !strings.HasPrefix(c.RabbitMQURL, "amqps://") {
+		add("RABBITMQ_URL", c.RabbitMQURL, "must use the amqp:// or amqps:// scheme")
+	}
+	if (c.RabbitMQTLSCertFile == "") != (c.RabbitMQTLSKeyFile == "") {
+		add("RABBITMQ_TLS_KEY_FILE", c.RabbitMQTLSKeyFile, "must be set together with RABBITMQ_TLS_CERT_FILE")
+	}
+	if c.AMQP.TLSClientConfig != nil && !strings.HasPrefix(c.RabbitMQURL, "amqps://") {
+		add("RABBITMQ_URL", c.RabbitMQURL, "must use amqps:// when RABBITMQ_TLS_* files are set")
+	}
+	if c.RabbitMQConnectTimeout < time.Second {
+		add("RABBITMQ_CONNECT_TIMEOUT_SEC", c.RabbitMQConnectTimeout, "must be at least 1s")
+	}
+	if c.RabbitMQMaxRetries < 1 {
+		add("RABBITMQ_MAX_RETRIES", c.RabbitMQMaxRetries, "must be at least 1")
+	}
+	if c.RabbitMQRetryInterval < 0 {
+		add("RABBITMQ_RETRY_INTERVAL_SEC", c.RabbitMQRetryInterval, "must not be negative")
+	}
+	if c.RabbitMQHeartbeatSec < 0 {
+		add("RABBITMQ_HEARTBEAT_SEC", c.RabbitMQHeartbeatSec, "must not be negative")
+	}
+	if c.DefaultJobPriority < 0 || c.DefaultJobPriority > 9 {
+		add("DEFAULT_JOB_PRIORITY", c.DefaultJobPriority, "must be in the range [0, 9]")
+	}
+	if c.ExchangeType != "direct" && c.ExchangeType != "topic" {
+		add("EXCHANGE_TYPE", c.ExchangeType, "must be direct or topic")
+	}
+	if c.ConsumerQueue == "" {
+		add("CONSUMER_QUEUE", c.ConsumerQueue, "must not be empty")
+	}
+	if c.ExchangeType == "topic" && c.TopicBindingKey == "" {
+		add("TOPIC_BINDING_KEY", c.TopicBindingKey, "must not be empty")
+	}
+	if len(c.ConsumerQueues) > 0 && c.ExchangeType == "direct" && !c.consumesRetries() {
+		add("CONSUMER_QUEUES", c.ConsumerQueues, "must include a queue bound to transcription.request, where retries are routed")
+	}
+	if c.RabbitMQManagementURL != "" && !strings.HasPrefix(c.RabbitMQManagementURL, "http://") && !strings.HasPrefix(c.RabbitMQManagementURL, "https://") {
+		add("RABBITMQ_MANAGEMENT_URL", c.RabbitMQManagementURL, "must use the http:// or https:// scheme")
+	}
+	if c.LagCacheTTL < 0 {
+		add("LAG_CACHE_TTL_SEC", c.LagCacheTTL, "must not be negative")
+	}
+	if c.MaxJobAgeWarn < 0 {
+		add("MAX_JOB_AGE_WARN_SEC", c.MaxJobAgeWarn, "must not be negative")
+	}
+	if c.DedupCacheSize < 0 {
+		add("DEDUP_CACHE_SIZE", c.DedupCacheSize, "must not be negative")
+	}
+	if c.DedupTTL < 0 {
+		add("DEDUP_TTL_SEC", c.DedupTTL, "must not be negative")
+	}
+	if c.MaxInboundMessageSizeBytes < 0 {
+		add("MAX_INBOUND_MESSAGE_SIZE_BYTES", c.MaxInboundMessageSizeBytes, "must not be negative")
+	}
+	if c.ConsumerTagPrefix == "" {
+		add("CONSUMER_TAG_PREFIX", c.ConsumerTagPrefix, "must not be empty")
+	}
+
+	if c.RetryBaseDelayMs < 1 {
+		add("RETRY_BASE_DELAY_MS", c.RetryBaseDelayMs, "must be at least 1")
+	}
+	if c.RetryMaxDelayMs < c.RetryBaseDelayMs {
+		add("RETRY_MAX_DELAY_MS", c.RetryMaxDelayMs, "must not be lower than RETRY_BASE_DELAY_MS")
+	}
+	if c.RetryJitterPct < 0 || c.RetryJitterPct >= 1 {
+		add("RETRY_JITTER_PCT", c.RetryJitterPct, "must be in the range [0, 1)")
+	}
+
+	if c.PublishConfirmTimeout < time.Second {
+		add("PUBLISH_CONFIRM_TIMEOUT_SEC", c.PublishConfirmTimeout, "must be at least 1s")
+	}
+	if c.CallbackTimeout < time.Second {
+		add("CALLBACK_TIMEOUT_SEC", c.CallbackTimeout, "must be at least 1s")
+	}
+	if c.MaxCallbackConcurrency < 1 {
+		add("MAX_CALLBACK_CONCURRENCY", c.MaxCallbackConcurrency, "must be at least 1")
+	}
+	if c.MaxMessageSizeBytes != 0 && c.MaxMessageSizeBytes < 4096 {
+		add("MAX_MESSAGE_SIZE_BYTES", c.MaxMessageSizeBytes, "must be 0 or at least 4096")
+	}
+	if c.ResultTTLMs < 0 {
+		add("RESULT_TTL_MS", c.ResultTTLMs, "must not be negative")
+	}
+	if strings.HasPrefix(c.AuditExchange, "amq.") {
+		add("AUDIT_EXCHANGE", c.AuditExchange, "must not use the reserved amq. prefix")
+	}
+
+	if c.MaxWorkers < 1 {
+		add("WORKERS_COUNT", c.MaxWorkers, "must be at least 1")
+	}
+	if c.WorkerCPUFraction <= 0 {
+		add("WORKER_CPU_FRACTION", c.WorkerCPUFraction, "must be greater than 0")
+	}
+	if c.MaxWorkersHardLimit < 1 {
+		add("MAX_WORKERS_HARD_LIMIT", c.MaxWorkersHardLimit, "must be at least 1")
+	}
+	if c.ProcessIdleTimeout < time.Second {
+		add("PROCESS_IDLE_TIMEOUT_MIN", c.ProcessIdleTimeout, "must be at least 1s")
+	}
+
+	if c.JobTimeout < 0 {
+		add("JOB_TIMEOUT_SEC", c.JobTimeout, "must not be negative")
+	}
+	if c.ShutdownTimeout < time.Second {
+		add("SHUTDOWN_TIMEOUT_SEC", c.ShutdownTimeout, "must be at least 1s")
+	}
+	if c.PauseWarnAfter < time.Second {
+		add("PAUSE_WARN_AFTER_SEC", c.PauseWarnAfter, "must be at least 1s")
+	}
+	if c.HeartbeatInterval < time.Second {
+		add("WORKER_HEARTBEAT_INTERVAL_SEC", c.HeartbeatInterval, "must be at least 1s")
+	}
+	if c.HeartbeatTimeout < 0 {
+		add("WORKER_HEARTBEAT_TIMEOUT_SEC", c.HeartbeatTimeout, "must not be negative")
+	} else if c.HeartbeatTimeout > 0 && c.HeartbeatTimeout <= c.HeartbeatInterval {
+		add("WORKER_HEARTBEAT_TIMEOUT_SEC", c.HeartbeatTimeout, "must be longer than WORKER_HEARTBEAT_INTERVAL_SEC")
+	}
+	if c.MemoryLimitMB < 0 {
+		add("MEMORY_LIMIT_MB", c.MemoryLimitMB, "must not be negative")
+	}
+	if c.JobChannelBuffer < 0 {
+		add("JOB_CHANNEL_BUFFER", c.JobChannelBuffer, "must not be negative")
+	}
+	if c.MaxConcurrentSpawns < 0 {
+		add("MAX_CONCURRENT_SPAWNS", c.MaxConcurrentSpawns, "must not be negative")
+	}
+	if c.SpareProcesses < 0 {
+		add("SPARE_PROCESSES", c.SpareProcesses, "must not be negative")
+	}
+	if c.JobsPerSecond < 0 {
+		add("JOBS_PER_SECOND", c.JobsPerSecond, "must not be negative")
+	}
+	if c.MaxJobQueueAge < 0 {
+		add("MAX_JOB_QUEUE_AGE_SEC", c.MaxJobQueueAge, "must not be negative")
+	}
+	if c.RecentJobsSize < 1 {
+		add("RECENT_JOBS_SIZE", c.RecentJobsSize, "must be at least 1")
+	}
+	if c.IdleShutdownGracePeriod < 0 {
+		add("IDLE_SHUTDOWN_GRACE_PERIOD_SEC", c.IdleShutdownGracePeriod, "must not be negative")
+	}
+	if c.MaxSpawnBackoff < time.Second {
+		add("MAX_SPAWN_BACKOFF_SEC", c.MaxSpawnBackoff, "must be at least 1s")
+	}
+	if c.SpawnReadyTimeout < time.Second {
+		add("SPAWN_READY_TIMEOUT_SEC", c.SpawnReadyTimeout, "must be at least 1s")
+	}
+	if c.DryRun && c.PreloadOnly {
+		add("DRY_RUN", c.DryRun, "cannot be combined with PRELOAD_ONLY")
+	}
+
+	if c.PythonPath == "" || !filepath.IsAbs(c.PythonPath) {
+		add("PYTHON_PATH", c.PythonPath, "must be an absolute path")
+	} else if err := validateExecutable(c.PythonPath); err != nil && !c.DryRun {
+		add("PYTHON_PATH", c.PythonPath, err.Error())
+	}
+	if c.WorkerScript == "" {
+		add("WORKER_SCRIPT", c.WorkerScript, "must not be empty")
+	} else if err := validateReadable(c.workerScriptPath()); err != nil && !c.DryRun {
+		add("WORKER_SCRIPT", c.WorkerScript, err.Error())
+	}
+	if c.WorkerWorkDir != "" {
+		if info, err := os.Stat(c.WorkerWorkDir); err != nil {
+			add("WORKER_WORKDIR", c.WorkerWorkDir, err.Error())
+		} else if !info.IsDir() {
+			add("WORKER_WORKDIR", c.WorkerWorkDir, "is not a directory")
+		}
+	}
+	for _, entry := range c.ExtraPythonEnv {
+		if key, _, ok := strings.Cut(entry, "="); !ok || key == "" || strings.ContainsAny(key, " \t") {
+			add("EXTRA_PYTHON_ENV", entry, "must be KEY=VALUE")
+		}
+	}
+	if c.PingEnabled && c.PingTimeoutMs < 1 {
+		add("PING_TIMEOUT_MS", c.PingTimeoutMs, "must be at least 1")
+	}
+
+	if c.MaxFileSizeMB < 1 {
+		add("MAX_FILE_SIZE_MB", c.MaxFileSizeMB, "must be at least 1")
+	}
+	if c.MaxAudioDurationSec < 1 {
+		add("MAX_AUDIO_DURATION_SEC", c.MaxAudioDurationSec, "must be at least 1")
+	}
+	for _, dir := range c.AllowedAudioDirs {
+		if !filepath.IsAbs(dir) {
+			add("ALLOWED_AUDIO_DIRS", dir, "must be an absolute path")
+		}
+	}
+	if len(c.SupportedExtensions) == 0 {
+		add("SUPPORTED_EXTENSIONS", c.SupportedExtensions, "must list at least one extension")
+	}
+	if c.FfprobePath == "" {
+		add("FFPROBE_PATH", c.FfprobePath, "must not be empty")
+	}
+	if c.AudioSampleRate <= 0 || c.AudioSampleRate%100 != 0 {
+		add("AUDIO_SAMPLE_RATE", c.AudioSampleRate, "must be a positive multiple of 100")
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		add("LOG_LEVEL", c.LogLevel, "must be one of debug, info, warn, error")
+	}
+	if c.LogFormat != logging.FormatText && c.LogFormat != logging.FormatJSON {
+		add("LOG_FORMAT", c.LogFormat, "must be text or json")
+	}
+
+	if c.OTLPEndpoint != "" && !strings.HasPrefix(c.OTLPEndpoint, "http://") && !strings.HasPrefix(c.OTLPEndpoint, "https://") {
+		add("OTEL_EXPORTER_OTLP_ENDPOINT", c.OTLPEndpoint, "must be an http:// or https:// URL")
+	}
+
+	if c.ReloadInterval < 0 {
+		add("CONFIG_RELOAD_INTERVAL_SEC", c.ReloadInterval, "must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// consumesRetries reports whether a ConsumerQueues entry receives the
+// transcription.request routing key that direct mode republishes retries with.
+func (c *Config) consumesRetries() bool {
+	for _, queue := range c.ConsumerQueues {
+		if queue.RoutingKey == "" || queue.RoutingKey == "transcription.request" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExecutable checks that path names an executable file. A path
+// without a directory is looked up in PATH.
+func validateExecutable(path string) error {
+	if !strings.ContainsRune(path, filepath.Separator) {
+		if _, err := exec.LookPath(path); err != nil {
+			return fmt.Errorf("is not an executable in PATH")
+		}
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("does not exist")
+	}
+	if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("is not executable")
+	}
+	return nil
+}
+
+// validateReadable checks that path names a regular file that can be opened.
+func validateReadable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("does not exist")
+	}
+	if info.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("is not readable")
+	}
+	return f.Close()
+}
+
+// workerScriptPath returns WorkerScript as Python resolves it: relative
+// paths are taken from WORKER_WORKDIR when it is set.
+func (c *Config) workerScriptPath() string {
+	if filepath.IsAbs(c.WorkerScript) || c.WorkerWorkDir == "" {
+		return c.WorkerScript
+	}
+	return filepath.Join(c.WorkerWorkDir, c.WorkerScript)
+}
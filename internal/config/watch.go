@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// EnvFile is the dotenv file re-read by Watch.
+const EnvFile = ".env"
+
+// liveKeys lists the variables that can change without a restart.
+// Every other registered variable requires one.
+var liveKeys = map[string]bool{
+	"WORKERS_COUNT": true,
+	"WHISPER_MODEL": true,
+	"LOG_LEVEL":     true,
+}
+
+// processEnv holds the variables set in the real environment at startup.
+// They take precedence over EnvFile, so Watch never overrides them.
+var processEnv = snapshotEnv()
+
+// snapshotEnv records which variables are set in the process environment.
+func snapshotEnv() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		keys[key] = true
+	}
+	return keys
+}
+
+// RequiresRestart reports whether a change to the environment variable key
+// only takes effect after a restart.
+func RequiresRestart(key string) bool {
+	return !liveKeys[key]
+}
+
+// Equal reports whether c and other were loaded from the same values.
+func (c *Config) Equal(other *Config) bool {
+	return len(c.Diff(other)) == 0
+}
+
+// Diff returns the environment variables whose values differ between c and
+// other, in registry order.
+func (c *Config) Diff(other *Config) []string {
+	var changed []string
+	for _, v := range envVars {
+		if c.raw[v.Key] != other.raw[v.Key] {
+			changed = append(changed, v.Key)
+		}
+	}
+	return changed
+}
+
+// Watch re-reads EnvFile and reloads the configuration every interval,
+// sending the new Config whenever a value changed. Variables set in the
+// process environment at startup are not overridden. Invalid reloads are
+// logged and skipped. The channel is closed when ctx is done.
+func (c *Config) Watch(ctx context.Context, interval time.Duration) <-chan *Config {
+	updates := make(chan *Config)
+
+	go func() {
+		defer close(updates)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		current := c
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next, err := Reload()
+			if err != nil {
+				slog.Warn("⚠️  Config reload rejected", slog.Any("error", err))
+				continue
+			}
+			if current.Equal(next) {
+				continue
+			}
+
+			select {
+			case updates <- next:
+				current = next
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates
+}
+
+// Reload re-reads EnvFile into the environment, without overriding the
+// variables set in the process environment at startup, and loads the
+// configuration again.
+func Reload() (*Config, error) {
+	if err := reloadEnvFile(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", EnvFile, err)
+	}
+	return Load()
+}
+
+// reloadEnvFile applies EnvFile values for registered variables that were not
+// set in the process environment at startup. A missing file is not an error.
+func reloadEnvFile() error {
+	values, err := godotenv.Read(EnvFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, v := range envVars {
+		if processEnv[v.Key] {
+			continue
+		}
+		if value, ok := values[v.Key]; ok {
+			os.Setenv(v.Key, value)
+		} else {
+			os.Unsetenv(v.Key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package health provides HTTP liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"whisper-local/internal/rabbitmq"
+)
+
+// ResizeResponse is the JSON body returned by POST /admin/workers.
+type ResizeResponse struct {
+	Old int `json:"old"`
+	New int `json:"new"`
+}
+
+// EnableAdmin registers the admin endpoints operating on the worker pool.
+func (s *Server) EnableAdmin() {
+	s.mux.HandleFunc("/admin/workers", s.handleResizeWorkers)
+	s.mux.HandleFunc("/admin/pause", s.handlePause)
+	s.mux.HandleFunc("/admin/resume", s.handleResume)
+	s.mux.HandleFunc("/admin/dlq/republish", s.handleRepublishDLQ)
+	s.mux.HandleFunc("/admin/jobs/recent", s.handleRecentJobs)
+	s.mux.HandleFunc("/admin/processes", s.handleProcesses)
+}
+
+// handleProcesses lists the job and error counts of every Python process,
+// keyed by model.
+func (s *Server) handleProcesses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.workerPool.ProcessStats())
+}
+
+// defaultRecentJobs is the number of jobs GET /admin/jobs/recent returns
+// without ?n=.
+const defaultRecentJobs = 20
+
+// handleRecentJobs lists the most recently finished jobs, newest first,
+// up to the ?n= query value.
+func (s *Server) handleRecentJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	n := defaultRecentJobs
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		var err error
+		if n, err = strconv.Atoi(raw); err != nil || n < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "n must be a positive integer"})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, s.workerPool.RecentJobs(n))
+}
+
+// DeadLetterRepublisher moves dead jobs back to the main queue.
+// *rabbitmq.DLQConsumer satisfies it.
+type DeadLetterRepublisher interface {
+	Republish(ctx context.Context, filter func(rabbitmq.DeadJob) bool) (int, error)
+}
+
+// WithDLQ enables POST /admin/dlq/republish on dlq.
+func (s *Server) WithDLQ(dlq DeadLetterRepublisher) *Server {
+	s.dlq = dlq
+	return s
+}
+
+// RepublishResponse is the JSON body returned by POST /admin/dlq/republish.
+type RepublishResponse struct {
+	Republished int    `json:"republished"`
+	Error       string `json:"error,omitempty"`
+}
+
+// handleRepublishDLQ republishes the dead jobs matching the JSON
+// rabbitmq.DeadJobFilter in the body; an empty body republishes all of them.
+func (s *Server) handleRepublishDLQ(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	if s.dlq == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "dead letter queue not available"})
+		return
+	}
+
+	var filter rabbitmq.DeadJobFilter
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&filter); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid filter: " + err.Error()})
+		return
+	}
+
+	republished, err := s.dlq.Republish(r.Context(), filter.Match)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, RepublishResponse{Republished: republished, Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, RepublishResponse{Republished: republished})
+}
+
+// PauseResponse is the JSON body returned by POST /admin/pause and /admin/resume.
+type PauseResponse struct {
+	Paused  bool `json:"paused"`
+	Changed bool `json:"changed"`
+}
+
+// handlePause stops the worker pool from taking new jobs.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	changed := s.workerPool.Pause()
+	writeJSON(w, http.StatusOK, PauseResponse{Paused: true, Changed: changed})
+}
+
+// handleResume lets the worker pool take jobs again.
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	changed := s.workerPool.Resume()
+	writeJSON(w, http.StatusOK, PauseResponse{Paused: false, Changed: changed})
+}
+
+// requirePost rejects non-POST requests with 405 and reports whether to continue.
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return false
+	}
+	return true
+}
+
+// handleResizeWorkers resizes the worker pool to the ?count= query value.
+func (s *Server) handleResizeWorkers(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 1 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "count must be a positive integer"})
+		return
+	}
+
+	old := s.workerPool.NumWorkers()
+	if err := s.workerPool.Resize(count); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, ResizeResponse{Old: old, New: s.workerPool.NumWorkers()})
+}
@@ -0,0 +1,188 @@
+// Package health provides HTTP liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"whisper-local/internal/config"
+	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/worker"
+)
+
+// Component status values.
+const (
+	StatusOK       = "ok"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// ComponentStatus is the health of a single subsystem.
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Alive  *int   `json:"alive,omitempty"`
+	Total  *int   `json:"total,omitempty"`
+}
+
+// ReadyResponse is the JSON body returned by /health/ready.
+type ReadyResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+	Stats      worker.PoolStats           `json:"stats"`
+	Throttled  bool                       `json:"throttled"` // consumer held back by its rate limit
+}
+
+// BrokerConnection reports whether the RabbitMQ connection is open.
+// *rabbitmq.ManagedConnection satisfies it.
+type BrokerConnection interface {
+	IsConnected() bool
+}
+
+// TopologyChecker verifies that the broker exchanges and queues exist.
+// *rabbitmq.HealthChecker satisfies it.
+type TopologyChecker interface {
+	CheckTopology(ctx context.Context) []rabbitmq.TopologyError
+}
+
+// topologyCheckTimeout bounds the topology check of a readiness probe.
+const topologyCheckTimeout = 5 * time.Second
+
+// Server exposes the health endpoints over HTTP.
+type Server struct {
+	workerPool *worker.Pool
+	conn       BrokerConnection
+	consumer   rabbitmq.JobConsumer
+	dlq        DeadLetterRepublisher
+	topology   TopologyChecker // nil skips the topology check
+	mux        *http.ServeMux
+	srv        *http.Server
+}
+
+// NewServer creates a health server listening on cfg.HealthPort.
+func NewServer(workerPool *worker.Pool, conn BrokerConnection, consumer rabbitmq.JobConsumer, cfg *config.Config) *Server {
+	s := &Server{
+		workerPool: workerPool,
+		conn:       conn,
+		consumer:   consumer,
+		mux:        http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/health/live", s.handleLive)
+	s.mux.HandleFunc("/health/ready", s.handleReady)
+
+	s.srv = &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.HealthPort),
+		Handler:           s.mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// WithTopologyCheck makes /health/ready also verify the broker topology
+// with checker, reporting not ready while an exchange or queue is missing.
+func (s *Server) WithTopologyCheck(checker TopologyChecker) *Server {
+	s.topology = checker
+	return s
+}
+
+// Start serves requests in a background goroutine.
+func (s *Server) Start() {
+	go func() {
+		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("❌ Health server", slog.Any("error", err))
+		}
+	}()
+	slog.Info("🩺 Health server started", slog.String("addr", s.srv.Addr))
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// handleLive reports that the process is running.
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// handleReady reports whether the orchestrator can process jobs.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	resp := s.Ready()
+
+	code := http.StatusOK
+	if resp.Status != StatusReady {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, resp)
+}
+
+// Ready evaluates every component and returns the readiness report.
+func (s *Server) Ready() ReadyResponse {
+	resp := ReadyResponse{
+		Status:     StatusReady,
+		Components: make(map[string]ComponentStatus),
+		Stats:      s.workerPool.Stats(),
+		Throttled:  s.consumer.Throttled(),
+	}
+
+	total, alive := resp.Stats.Total, resp.Stats.Alive
+	workers := ComponentStatus{Status: StatusOK, Alive: &alive, Total: &total}
+	if alive < 1 {
+		workers.Status = StatusDown
+		workers.Detail = "no alive Python processes"
+	}
+	resp.Components["python_workers"] = workers
+
+	broker := ComponentStatus{Status: StatusOK}
+	if !s.conn.IsConnected() {
+		broker.Status = StatusDown
+		broker.Detail = "connection closed, reconnecting"
+	} else if !s.consumer.IsChannelOpen() {
+		broker.Status = StatusDown
+		broker.Detail = "consumer channel closed"
+	}
+	resp.Components["rabbitmq"] = broker
+
+	// Passive declarations need a working connection; skip them otherwise
+	if s.topology != nil && broker.Status == StatusOK {
+		resp.Components["rabbitmq_topology"] = s.checkTopology()
+	}
+
+	for _, c := range resp.Components {
+		if c.Status != StatusOK {
+			resp.Status = StatusNotReady
+		}
+	}
+	return resp
+}
+
+// checkTopology runs the topology check and summarizes missing resources.
+func (s *Server) checkTopology() ComponentStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), topologyCheckTimeout)
+	defer cancel()
+
+	errs := s.topology.CheckTopology(ctx)
+	if len(errs) == 0 {
+		return ComponentStatus{Status: StatusOK}
+	}
+
+	details := make([]string, len(errs))
+	for i, err := range errs {
+		details[i] = err.Error()
+	}
+	return ComponentStatus{Status: StatusDown, Detail: strings.Join(details, "; ")}
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
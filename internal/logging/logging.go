@@ -0,0 +1,58 @@
+// Package logging configures the process-wide structured logger.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Output formats accepted by Setup.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level is the minimum level of the default logger, adjustable at runtime.
+var level slog.LevelVar
+
+// ParseLevel converts a LOG_LEVEL value (debug, info, warn, error) to a slog.Level.
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", value)
+	}
+	return level, nil
+}
+
+// Setup installs a slog handler writing to w as the default logger.
+// Calls to the standard log package are routed through it as well.
+func Setup(w io.Writer, lvl, format string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: &level}
+
+	var handler slog.Handler
+	switch format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel changes the minimum level of the logger installed by Setup.
+func SetLevel(value string) error {
+	lvl, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
@@ -0,0 +1,336 @@
+// Package metrics provides Prometheus-compatible instruments and an HTTP exporter.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Instruments exported by the orchestrator.
+var (
+	JobsTotal = NewCounterVec(
+		"whisper_jobs_total",
+		"Transcription jobs handled, by outcome (success, error, retry, duplicate).",
+		"status",
+	)
+	JobDuration = NewHistogramVec(
+		"whisper_job_duration_seconds",
+		"Time spent in the Python worker per successful job.",
+		[]float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+		"model",
+	)
+	WorkersBusy = NewGauge(
+		"whisper_workers_busy",
+		"Workers currently processing a job.",
+	)
+	ProcessRestarts = NewCounter(
+		"whisper_process_restarts_total",
+		"Python worker processes respawned after dying.",
+	)
+	ProcessStartup = NewHistogramVec(
+		"whisper_process_startup_seconds",
+		"Time from starting a Python worker process to its READY signal.",
+		[]float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	)
+
+	WorkerPanics = NewCounter(
+		"whisper_worker_panics_total",
+		"Panics recovered while processing a job.",
+	)
+	QueueWait = NewHistogramVec(
+		"whisper_queue_wait_seconds",
+		"Time from publishing a job (x-source-timestamp header) to a worker picking it up.",
+		[]float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 3600},
+	)
+	JobLatency = NewHistogramVec(
+		"whisper_job_latency_seconds",
+		"Time from a job's submitted_at to its successful result being published.",
+		[]float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	)
+	QueueDepth = NewGauge(
+		"whisper_queue_depth",
+		"Jobs buffered in the worker pool waiting for a worker.",
+	)
+	ConnectionBlocked = NewGauge(
+		"whisper_rabbitmq_connection_blocked",
+		"1 while the broker is blocking the connection for flow control.",
+	)
+)
+
+// collector is a metric that can write itself in the text exposition format.
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+// register adds c to the default registry.
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// WriteAll writes every registered metric in the Prometheus text format.
+func WriteAll(w io.Writer) {
+	registryMu.Lock()
+	collectors := append([]collector(nil), registry...)
+	registryMu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// desc holds the identity shared by every metric type.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+// header writes the HELP and TYPE lines.
+func (d *desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+// key identifies a series by its label values.
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats label names and values as {a="x",b="y"}.
+func labelPairs(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.Quote(values[i])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns map keys in a stable order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// value is a single float series.
+type value struct {
+	labels []string
+	v      float64
+}
+
+// valueVec is the storage shared by counters and gauges.
+type valueVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*value
+}
+
+func newValueVec(kind, name, help string, labels []string) *valueVec {
+	v := &valueVec{
+		desc:   desc{name: name, help: help, kind: kind, labels: labels},
+		series: make(map[string]*value),
+	}
+	if len(labels) == 0 {
+		v.series[""] = &value{}
+	}
+	register(v)
+	return v
+}
+
+func (vv *valueVec) update(labelValues []string, fn func(float64) float64) {
+	key := vv.key(labelValues)
+
+	vv.mu.Lock()
+	defer vv.mu.Unlock()
+
+	s, ok := vv.series[key]
+	if !ok {
+		s = &value{labels: append([]string(nil), labelValues...)}
+		vv.series[key] = s
+	}
+	s.v = fn(s.v)
+}
+
+func (vv *valueVec) write(w io.Writer) {
+	vv.mu.Lock()
+	defer vv.mu.Unlock()
+
+	vv.header(w)
+	for _, key := range sortedKeys(vv.series) {
+		s := vv.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", vv.name, labelPairs(vv.labels, s.labels), formatFloat(s.v))
+	}
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels.
+type CounterVec struct{ vec *valueVec }
+
+// NewCounterVec registers a counter with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec: newValueVec("counter", name, help, labels)}
+}
+
+// NewCounter registers a counter without labels.
+func NewCounter(name, help string) *CounterVec {
+	return NewCounterVec(name, help)
+}
+
+// Inc adds one to the series identified by labelValues.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta (which must be non-negative) to the series.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.vec.update(labelValues, func(v float64) float64 { return v + delta })
+}
+
+// GaugeVec is a value that can go up and down, partitioned by labels.
+type GaugeVec struct{ vec *valueVec }
+
+// NewGaugeVec registers a gauge with the given label names.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{vec: newValueVec("gauge", name, help, labels)}
+}
+
+// NewGauge registers a gauge without labels.
+func NewGauge(name, help string) *GaugeVec {
+	return NewGaugeVec(name, help)
+}
+
+// Set sets the series to v.
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.vec.update(labelValues, func(float64) float64 { return v })
+}
+
+// Add adds delta to the series.
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.vec.update(labelValues, func(v float64) float64 { return v + delta })
+}
+
+// Inc adds one to the series.
+func (g *GaugeVec) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec subtracts one from the series.
+func (g *GaugeVec) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+// GaugeFunc is a gauge without labels whose value is read at scrape time.
+type GaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc registers a gauge that reports the value returned by fn.
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, kind: "gauge"}, fn: fn}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// histogram is a single bucketed series.
+type histogram struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec samples observations into cumulative buckets, partitioned by labels.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds and label names.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: sorted,
+		series:  make(map[string]*histogram),
+	}
+	register(h)
+	return h
+}
+
+// Observe records v in the series identified by labelValues.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w)
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			values := append(append([]string(nil), s.labels...), formatFloat(upper))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(bucketLabels, values), s.counts[i])
+		}
+		values := append(append([]string(nil), s.labels...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(bucketLabels, values), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelPairs(h.labels, s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelPairs(h.labels, s.labels), s.count)
+	}
+}
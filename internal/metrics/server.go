@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Handler serves all registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteAll(w)
+	})
+}
+
+// Serve starts an HTTP server exposing /metrics on the given port.
+func Serve(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("❌ Metrics server", slog.Any("error", err))
+		}
+	}()
+
+	slog.Info("📊 Metrics server started", slog.Int("port", port), slog.String("path", "/metrics"))
+	return srv
+}
@@ -0,0 +1,85 @@
+package rabbitmq
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Confirmation is a pending publisher confirm. *amqp.DeferredConfirmation
+// implements it.
+type Confirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// Channel is the part of an AMQP channel used by Consumer and Producer.
+// The methods match *amqp.Channel except Publish, which publishes with a
+// deferred confirmation.
+type Channel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Confirm(noWait bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (Confirmation, error)
+	NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	NotifyCancel(c chan string) chan string
+	IsClosed() bool
+	Close() error
+}
+
+// Broker opens the channels of a Consumer or Producer. AMQPBroker adapts a
+// real connection; rabbitmqtest.MockBroker runs in memory.
+type Broker interface {
+	OpenChannel() (Channel, error)
+}
+
+// AMQPBroker returns a Broker opening channels on conn. While conn is a
+// *ManagedConnection re-dialing, consumers wait for it before resubscribing.
+func AMQPBroker(conn ChannelSource) Broker {
+	return amqpBroker{conn: conn}
+}
+
+// amqpBroker is the Broker of a ChannelSource.
+type amqpBroker struct {
+	conn ChannelSource
+}
+
+// OpenChannel opens a channel on the connection.
+func (b amqpBroker) OpenChannel() (Channel, error) {
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return amqpChannel{ch}, nil
+}
+
+// IsConnected reports whether the connection can open channels. Connections
+// that do not re-dial on their own are always reported as connected.
+func (b amqpBroker) IsConnected() bool {
+	waiter, ok := b.conn.(readyWaiter)
+	return !ok || waiter.IsConnected()
+}
+
+// WaitUntilReady waits for a re-dialing connection, see IsConnected.
+func (b amqpBroker) WaitUntilReady(ctx context.Context) error {
+	if waiter, ok := b.conn.(readyWaiter); ok {
+		return waiter.WaitUntilReady(ctx)
+	}
+	return nil
+}
+
+// amqpChannel adapts an *amqp.Channel to Channel.
+type amqpChannel struct {
+	*amqp.Channel
+}
+
+// Publish publishes msg and returns its deferred confirmation.
+func (c amqpChannel) Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (Confirmation, error) {
+	confirm, err := c.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		return nil, err
+	}
+	return confirm, nil
+}
@@ -0,0 +1,142 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrMessageTooLarge is returned when a message body exceeds the configured
+// maximum size and cannot be split.
+var ErrMessageTooLarge = errors.New("message exceeds max size")
+
+// chunkOverhead reserves room for the chunk_index, total_chunks and
+// segments fields added to each chunk.
+const chunkOverhead = 96
+
+// splitResult splits result into chunks whose JSON encoding fits in maxSize
+// bytes. Texto is cut on rune boundaries and segments are packed in order
+// after it; concatenating the chunks' Texto and Segments by ChunkIndex
+// rebuilds the original result.
+func splitResult(result TranscriptionResult, maxSize int) ([]TranscriptionResult, error) {
+	budget, err := contentBudget(result, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := result
+	meta.Texto, meta.Segments = "", nil
+
+	var chunks []TranscriptionResult
+	var used []int // bytes of Texto and Segments in each chunk
+
+	for rest := result.Texto; rest != ""; {
+		n, size := fitText(rest, budget)
+		chunk := meta
+		chunk.Texto = rest[:n]
+		chunks = append(chunks, chunk)
+		used = append(used, size)
+		rest = rest[n:]
+	}
+
+	for _, segment := range result.Segments {
+		encoded, err := json.Marshal(segment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal segment: %w", err)
+		}
+		size := len(encoded) + 1 // separating comma
+		if size > budget {
+			return nil, fmt.Errorf("%w: segment at %.2fs is %d bytes", ErrMessageTooLarge, segment.Start, size)
+		}
+
+		last := len(chunks) - 1
+		if last < 0 || used[last]+size > budget {
+			chunks = append(chunks, meta)
+			used = append(used, 0)
+			last++
+		}
+		chunks[last].Segments = append(chunks[last].Segments, segment)
+		used[last] += size
+	}
+
+	for i := range chunks {
+		chunks[i].ChunkIndex = i + 1
+		chunks[i].TotalChunks = len(chunks)
+	}
+	return chunks, nil
+}
+
+// truncateResult drops the segments of result and cuts Texto so its JSON
+// encoding fits in maxSize bytes, recording what was lost in Warning.
+func truncateResult(result TranscriptionResult, maxSize int) (TranscriptionResult, error) {
+	result.Warning = fmt.Sprintf("result exceeded %d bytes: texto truncated, segments dropped", maxSize)
+	budget, err := contentBudget(result, maxSize)
+	if err != nil {
+		return TranscriptionResult{}, err
+	}
+
+	original := len(result.Texto)
+	n, _ := fitText(result.Texto, budget)
+	result.Texto = result.Texto[:n]
+	result.Segments = nil
+	if n == original {
+		result.Warning = fmt.Sprintf("result exceeded %d bytes: segments dropped", maxSize)
+	} else {
+		result.Warning = fmt.Sprintf("result exceeded %d bytes: texto truncated to %d of %d bytes, segments dropped", maxSize, n, original)
+	}
+	return result, nil
+}
+
+// contentBudget returns the bytes left for Texto and Segments once the rest
+// of result is encoded.
+func contentBudget(result TranscriptionResult, maxSize int) (int, error) {
+	result.Texto, result.Segments = "", nil
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	budget := maxSize - len(encoded) - chunkOverhead
+	if budget <= 0 {
+		return 0, fmt.Errorf("%w: %d bytes leave no room for the transcription", ErrMessageTooLarge, maxSize)
+	}
+	return budget, nil
+}
+
+// fitText returns the length of the longest prefix of text, cut on a rune
+// boundary, whose JSON encoding adds at most budget bytes, and that size.
+func fitText(text string, budget int) (int, int) {
+	lo, hi := 0, len(text)
+	best, bestSize := 0, 0
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		n := mid
+		for n > 0 && n < len(text) && !utf8.RuneStart(text[n]) {
+			n--
+		}
+
+		size := encodedTextSize(text[:n])
+		if size <= budget {
+			if n > best {
+				best, bestSize = n, size
+			}
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	// Always make progress, even if a single rune needs escaping
+	if best == 0 && text != "" {
+		_, width := utf8.DecodeRuneInString(text)
+		return width, encodedTextSize(text[:width])
+	}
+	return best, bestSize
+}
+
+// encodedTextSize is the JSON size of text without the surrounding quotes.
+func encodedTextSize(text string) int {
+	encoded, _ := json.Marshal(text)
+	return len(encoded) - 2
+}
@@ -2,36 +2,340 @@
 package rabbitmq
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+
+	"whisper-local/internal/metrics"
 )
 
 const (
-	// Connection retry settings
-	maxRetries    = 10
-	retryInterval = 5 * time.Second
+	// Initial connection retry defaults
+	defaultMaxRetries    = 10
+	defaultRetryInterval = 5 * time.Second
+
+	// Dial defaults matching amqp.Dial
+	defaultHeartbeat = 10 * time.Second
+	defaultLocale    = "en_US"
 )
 
-// Connect establishes a connection to RabbitMQ with retry logic.
+// Connect establishes a connection to RabbitMQ with retry logic, using the
+// same defaults as amqp.Dial.
 func Connect(url string) (*amqp.Connection, error) {
+	return ConnectWithConfig(url, amqp.Config{Heartbeat: defaultHeartbeat})
+}
+
+// ConnectWithConfig establishes a connection to RabbitMQ with retry logic
+// using cfg for TLS, heartbeat and vhost settings. A zero cfg.Heartbeat uses
+// the broker's interval; TLSClientConfig only applies to amqps:// URLs.
+func ConnectWithConfig(url string, cfg amqp.Config) (*amqp.Connection, error) {
+	return connectWithRetry(url, cfg, defaultMaxRetries, defaultRetryInterval)
+}
+
+// connectWithRetry dials RabbitMQ up to maxRetries times, waiting
+// retryInterval between attempts. How long each attempt may take is set by
+// cfg.Dial.
+func connectWithRetry(url string, cfg amqp.Config, maxRetries int, retryInterval time.Duration) (*amqp.Connection, error) {
+	if cfg.Locale == "" {
+		cfg.Locale = defaultLocale
+	}
+
 	var conn *amqp.Connection
 	var err error
 
 	for i := 0; i < maxRetries; i++ {
-		conn, err = amqp.Dial(url)
+		conn, err = amqp.DialConfig(url, cfg)
 		if err == nil {
-			log.Println("📡 RabbitMQ connected")
+			slog.Info("📡 RabbitMQ connected")
 			return conn, nil
 		}
 
 		if i < maxRetries-1 {
-			log.Printf("⚠️  RabbitMQ retry %d/%d in %v...", i+1, maxRetries, retryInterval)
+			slog.Warn("⚠️  RabbitMQ connect failed, retrying",
+				slog.Int("attempt", i+1),
+				slog.Int("max_attempts", maxRetries),
+				slog.Duration("retry_in", retryInterval),
+				slog.Any("error", err))
 			time.Sleep(retryInterval)
 		}
 	}
 
 	return nil, fmt.Errorf("failed to connect after %d attempts: %w", maxRetries, err)
 }
+
+// Connection states reported by ConnectionWrapper.Stats.
+const (
+	StateConnected    = "connected"
+	StateReconnecting = "reconnecting"
+)
+
+// ConnectionStats is a snapshot of the RabbitMQ connection health.
+type ConnectionStats struct {
+	State          string
+	LocalAddr      net.Addr
+	RemoteAddr     net.Addr
+	IsBlocked      bool
+	ReconnectCount int64
+}
+
+// ConnectionWrapper wraps an AMQP connection and tracks its health.
+type ConnectionWrapper struct {
+	mu             sync.RWMutex
+	conn           *amqp.Connection
+	blocked        atomic.Bool
+	reconnectCount atomic.Int64
+}
+
+// NewConnectionWrapper wraps conn and starts watching for broker flow control.
+func NewConnectionWrapper(conn *amqp.Connection) *ConnectionWrapper {
+	w := &ConnectionWrapper{}
+	w.setConnection(conn)
+	return w
+}
+
+// Conn returns the current underlying connection.
+func (w *ConnectionWrapper) Conn() *amqp.Connection {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.conn
+}
+
+// setConnection installs conn and subscribes to its blocked notifications.
+func (w *ConnectionWrapper) setConnection(conn *amqp.Connection) {
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+
+	w.blocked.Store(false)
+	metrics.ConnectionBlocked.Set(0)
+	blocked := conn.NotifyBlocked(make(chan amqp.Blocking, 1))
+	go func() {
+		for b := range blocked {
+			if b.Active {
+				slog.Warn("⚠️  RabbitMQ blocked connection", slog.String("reason", b.Reason))
+			} else {
+				slog.Info("📡 RabbitMQ unblocked connection")
+			}
+			w.blocked.Store(b.Active)
+			if b.Active {
+				metrics.ConnectionBlocked.Set(1)
+			} else {
+				metrics.ConnectionBlocked.Set(0)
+			}
+		}
+	}()
+}
+
+// readyPollInterval is how often WaitUntilReady re-checks the connection.
+const readyPollInterval = 100 * time.Millisecond
+
+// IsConnected reports whether the underlying connection is open,
+// without opening a channel.
+func (w *ConnectionWrapper) IsConnected() bool {
+	conn := w.Conn()
+	return conn != nil && !conn.IsClosed()
+}
+
+// WaitUntilReady blocks until the connection is open or ctx is done.
+func (w *ConnectionWrapper) WaitUntilReady(ctx context.Context) error {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for !w.IsConnected() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the connection state.
+func (w *ConnectionWrapper) Stats() ConnectionStats {
+	conn := w.Conn()
+
+	stats := ConnectionStats{
+		State:          StateConnected,
+		IsBlocked:      w.blocked.Load(),
+		ReconnectCount: w.reconnectCount.Load(),
+	}
+	if conn == nil || conn.IsClosed() {
+		stats.State = StateReconnecting
+		return stats
+	}
+
+	stats.LocalAddr = conn.LocalAddr()
+	stats.RemoteAddr = conn.RemoteAddr()
+	return stats
+}
+
+// ChannelSource opens AMQP channels.
+// Both *amqp.Connection and *ManagedConnection satisfy it.
+type ChannelSource interface {
+	Channel() (*amqp.Channel, error)
+}
+
+// ReconnectConfig controls the backoff used by ManagedConnection.
+type ReconnectConfig struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+
+	// The first connection is attempted MaxRetries times, RetryInterval
+	// apart, before NewManagedConnection gives up
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+// DefaultReconnectConfig returns the backoff used when no config is given.
+func DefaultReconnectConfig() ReconnectConfig {
+	return ReconnectConfig{
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     60 * time.Second,
+		Multiplier:      2,
+		MaxRetries:      defaultMaxRetries,
+		RetryInterval:   defaultRetryInterval,
+	}
+}
+
+// next returns the backoff interval that follows interval.
+func (c ReconnectConfig) next(interval time.Duration) time.Duration {
+	interval = time.Duration(float64(interval) * c.Multiplier)
+	if interval > c.MaxInterval {
+		interval = c.MaxInterval
+	}
+	return interval
+}
+
+// ManagedConnection is a RabbitMQ connection that re-dials automatically
+// when the broker closes it.
+type ManagedConnection struct {
+	*ConnectionWrapper
+	url       string
+	amqpCfg   amqp.Config
+	cfg       ReconnectConfig
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewManagedConnection connects to RabbitMQ with amqpCfg and keeps the
+// connection alive, re-dialing with the same settings.
+func NewManagedConnection(url string, amqpCfg amqp.Config, cfg ReconnectConfig) (*ManagedConnection, error) {
+	defaults := DefaultReconnectConfig()
+	if cfg.InitialInterval <= 0 {
+		cfg.InitialInterval = defaults.InitialInterval
+	}
+	if cfg.MaxInterval < cfg.InitialInterval {
+		cfg.MaxInterval = cfg.InitialInterval
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = defaults.Multiplier
+	}
+	if cfg.MaxRetries < 1 {
+		cfg.MaxRetries = defaults.MaxRetries
+	}
+	if cfg.RetryInterval < 0 {
+		cfg.RetryInterval = defaults.RetryInterval
+	}
+	if amqpCfg.Locale == "" {
+		amqpCfg.Locale = defaultLocale
+	}
+
+	conn, err := connectWithRetry(url, amqpCfg, cfg.MaxRetries, cfg.RetryInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ManagedConnection{
+		ConnectionWrapper: NewConnectionWrapper(conn),
+		url:               url,
+		amqpCfg:           amqpCfg,
+		cfg:               cfg,
+		done:              make(chan struct{}),
+	}
+	go m.watch(conn)
+
+	return m, nil
+}
+
+// Channel opens a channel on the current connection.
+func (m *ManagedConnection) Channel() (*amqp.Channel, error) {
+	conn := m.Conn()
+	if conn.IsClosed() {
+		return nil, fmt.Errorf("rabbitmq connection is reconnecting")
+	}
+	return conn.Channel()
+}
+
+// watch re-dials whenever the current connection is closed by the broker.
+func (m *ManagedConnection) watch(conn *amqp.Connection) {
+	for {
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		select {
+		case <-m.done:
+			return
+		case amqpErr := <-closed:
+			if m.isClosing() {
+				return
+			}
+			slog.Warn("⚠️  RabbitMQ connection lost", slog.Any("error", amqpErr))
+		}
+
+		next, ok := m.reconnect()
+		if !ok {
+			return
+		}
+		conn = next
+	}
+}
+
+// reconnect dials with exponential backoff until it succeeds or Close is called.
+func (m *ManagedConnection) reconnect() (*amqp.Connection, bool) {
+	interval := m.cfg.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-m.done:
+			return nil, false
+		case <-time.After(interval):
+		}
+
+		conn, err := amqp.DialConfig(m.url, m.amqpCfg)
+		if err == nil {
+			m.setConnection(conn)
+			m.reconnectCount.Add(1)
+			slog.Info("📡 RabbitMQ reconnected", slog.Int("attempts", attempt))
+			return conn, true
+		}
+
+		interval = m.cfg.next(interval)
+		slog.Warn("⚠️  RabbitMQ reconnect failed",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", interval),
+			slog.Any("error", err))
+	}
+}
+
+// isClosing reports whether Close has been called.
+func (m *ManagedConnection) isClosing() bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops reconnecting and closes the current connection.
+func (m *ManagedConnection) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	return m.Conn().Close()
+}
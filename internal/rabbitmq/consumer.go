@@ -2,93 +2,329 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+
+	"whisper-local/internal/ratelimit"
+	"whisper-local/internal/telemetry"
 )
 
 const (
 	// Queue names
-	MainQueue   = "whisper_transcriptions"
-	MainExchange = "whisper_exchange"
+	MainQueue      = "whisper_transcriptions"
+	MainExchange   = "whisper_exchange"
 	MainRoutingKey = "transcription.request"
+
+	// MaxPriority is the x-max-priority of MainQueue
+	MaxPriority = 9
+
+	// DefaultConsumerTagPrefix starts the consumer tag unless overridden
+	DefaultConsumerTagPrefix = "go-orchestrator"
+
+	// Exchange types accepted by Topology
+	ExchangeDirect = "direct"
+	ExchangeTopic  = "topic"
 )
 
+// Topology selects how MainExchange routes requests to the consumer queue.
+type Topology struct {
+	ExchangeType string // ExchangeDirect (default) or ExchangeTopic
+	Queue        string // Consumer queue; MainQueue if empty
+	BindingKey   string // Topic only: binding pattern such as "es.#"; "#" if empty
+}
+
+// withDefaults fills the empty fields of t.
+func (t Topology) withDefaults() Topology {
+	if t.ExchangeType == "" {
+		t.ExchangeType = ExchangeDirect
+	}
+	if t.Queue == "" {
+		t.Queue = MainQueue
+	}
+	switch {
+	case t.ExchangeType == ExchangeDirect:
+		t.BindingKey = MainRoutingKey
+	case t.BindingKey == "":
+		t.BindingKey = "#"
+	}
+	return t
+}
+
+// RequestRoutingKey returns the topic routing key for a request in language,
+// "<language>.transcription.request". An empty language uses "any".
+func RequestRoutingKey(language string) string {
+	if language == "" {
+		language = "any"
+	}
+	return language + "." + MainRoutingKey
+}
+
 // Consumer handles consuming messages from RabbitMQ.
 type Consumer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   string
+	broker        Broker
+	mu            sync.Mutex // guards channel, replaced on reconnect
+	channel       Channel
+	queue         string
+	topology      Topology
+	tag           string
+	prefetchCount int
+	buckets       *priorityBuckets // nil unless per-priority prefetch is configured
+	limiter       *ratelimit.Limiter
+	throttled     throttleState
+	priority      uint8                           // default for messages published without one
+	maxJobAge     time.Duration                   // warn about older jobs; zero disables it
+	filter        func(TranscriptionRequest) bool // nil keeps every request
+	maxBodySize   int                             // larger bodies are archived; zero disables it
+	deadLetters   *Producer                       // archives oversized bodies, nil discards them
+	management    *managementAPI                  // nil unless Lag is enabled
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	// Redelivered messages already ACKed are ACKed again without processing
+	dedup             *dedupCache // nil unless deduplication is enabled
+	duplicatesSkipped atomic.Int64
+
+	oversizedDropped atomic.Int64
+}
+
+// ConsumerStats holds consumer counters.
+type ConsumerStats struct {
+	DuplicatesSkipped        int64 `json:"duplicates_skipped"`
+	OversizedMessagesDropped int64 `json:"oversized_messages_dropped"`
 }
 
 // Job represents a transcription job with its delivery for ACK/NACK.
 type Job struct {
-	Request  TranscriptionRequest
-	Delivery amqp.Delivery
+	Request    TranscriptionRequest
+	Delivery   amqp.Delivery
+	ReceivedAt time.Time // when the consumer handed the job over
+	EnqueuedAt time.Time // from SourceTimestampHeader, zero if the publisher did not set it
+
+	// Context carries the "job.receive" span, a child of the trace context
+	// found in the message headers. Span must be ended once the job is done.
+	Context context.Context
+	Span    *telemetry.Span
+}
+
+// NewConsumer creates a new RabbitMQ consumer that declares and consumes
+// the queue described by topology, keeping up to prefetch.GlobalPrefetch
+// deliveries in flight.
+func NewConsumer(conn ChannelSource, prefetch PrefetchConfig, topology Topology) (*Consumer, error) {
+	return newConsumer(AMQPBroker(conn), prefetch, topology.withDefaults())
+}
+
+// NewConsumerFromBroker is NewConsumer for channels opened by broker, such
+// as a rabbitmqtest.MockBroker.
+func NewConsumerFromBroker(broker Broker, prefetch PrefetchConfig, topology Topology) (*Consumer, error) {
+	return newConsumer(broker, prefetch, topology.withDefaults())
+}
+
+// newConsumer creates a consumer for a topology whose fields are all set.
+func newConsumer(broker Broker, prefetch PrefetchConfig, topology Topology) (*Consumer, error) {
+	prefetchCount := prefetch.GlobalPrefetch
+
+	channel, err := openConsumerChannel(broker, prefetchCount, topology)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Consumer{
+		broker:        broker,
+		channel:       channel,
+		queue:         topology.Queue,
+		topology:      topology,
+		tag:           consumerTag(DefaultConsumerTagPrefix),
+		prefetchCount: prefetchCount,
+		buckets:       newPriorityBuckets(prefetch.PerPriorityBuckets),
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
 }
 
-// NewConsumer creates a new RabbitMQ consumer.
-func NewConsumer(conn *amqp.Connection, prefetchCount int) (*Consumer, error) {
-	channel, err := conn.Channel()
+// openConsumerChannel opens a channel, declares the consumer topology and sets QoS.
+func openConsumerChannel(broker Broker, prefetchCount int, topology Topology) (Channel, error) {
+	channel, err := broker.OpenChannel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
 	// Declare topology
-	if err := declareConsumerTopology(channel); err != nil {
+	if err := declareConsumerTopology(channel, topology); err != nil {
 		channel.Close()
 		return nil, err
 	}
 
-	// Set QoS - prefetch count equals number of workers
+	// Set QoS per consumer; priority buckets are enforced in forward
 	if err := channel.Qos(prefetchCount, 0, false); err != nil {
 		channel.Close()
 		return nil, fmt.Errorf("failed to set QoS: %w", err)
 	}
 
-	return &Consumer{
-		conn:    conn,
-		channel: channel,
-		queue:   MainQueue,
-	}, nil
+	return channel, nil
+}
+
+// WithRateLimit limits how fast jobs are forwarded to rps per second, with a
+// burst equal to the prefetch count. A non-positive rps disables the limit.
+func (c *Consumer) WithRateLimit(rps float64) *Consumer {
+	if rps <= 0 {
+		c.limiter = nil
+		return c
+	}
+	c.limiter = ratelimit.NewLimiter(rps, c.prefetchCount)
+	slog.Info("Consumer rate limited", slog.Float64("rps", rps), slog.Int("burst", c.prefetchCount))
+	return c
+}
+
+// WithTagPrefix replaces the prefix of the consumer tag, which also carries
+// the hostname and process ID so instances can be told apart in the
+// management UI. It must be called before Consume.
+func (c *Consumer) WithTagPrefix(prefix string) *Consumer {
+	if prefix != "" {
+		c.tag = consumerTag(prefix)
+	}
+	return c
+}
+
+// consumerTag returns "<prefix>-<hostname>-<pid>".
+func consumerTag(prefix string) string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%s-%d", prefix, hostname, os.Getpid())
+}
+
+// WithDefaultPriority sets the priority assigned to requests whose message
+// carries none. Values above MaxPriority are clamped.
+func (c *Consumer) WithDefaultPriority(priority int) *Consumer {
+	c.priority = clampPriority(priority)
+	return c
+}
+
+// WithMaxJobAgeWarn logs a warning for every request received more than
+// maxAge after its SubmittedAt, to surface stuck jobs. Zero disables it.
+func (c *Consumer) WithMaxJobAgeWarn(maxAge time.Duration) *Consumer {
+	c.maxJobAge = maxAge
+	return c
+}
+
+// WithFilter discards, with an ACK, every request for which fn returns
+// false instead of emitting it as a Job. Use it to skip known-bad
+// attachments without redelivery loops while upstream data is fixed.
+func (c *Consumer) WithFilter(fn func(TranscriptionRequest) bool) *Consumer {
+	c.filter = fn
+	return c
+}
+
+// WithMaxMessageSize archives in the dead letter queue, through
+// deadLetters.PublishRejected, every message whose body is larger than
+// maxBytes instead of decoding it, then ACKs it. Requests only carry a
+// file path, so a large body usually means a publisher embedded the audio.
+// If deadLetters is nil or the publish fails the message is rejected
+// without requeue, which discards it since the consumed queue has no
+// dead letter exchange. Zero disables the check.
+func (c *Consumer) WithMaxMessageSize(maxBytes int, deadLetters *Producer) *Consumer {
+	c.maxBodySize = maxBytes
+	c.deadLetters = deadLetters
+	return c
+}
+
+// WithDeduplication remembers the last cacheSize messages ACKed, by
+// MessageId or a hash of the body, for up to ttl (zero keeps them until
+// evicted). A redelivered message found in the cache was already
+// processed, e.g. its ACK was written but the connection dropped before
+// the broker got it, so it is ACKed without processing instead of
+// producing a duplicate result. Messages are recorded only once their ACK
+// succeeds, so NACKed or in-flight ones are processed again. A
+// non-positive cacheSize disables deduplication.
+func (c *Consumer) WithDeduplication(cacheSize int, ttl time.Duration) *Consumer {
+	if cacheSize <= 0 {
+		c.dedup = nil
+		return c
+	}
+	c.dedup = newDedupCache(cacheSize, ttl)
+	return c
+}
+
+// Stats returns consumer counters.
+func (c *Consumer) Stats() ConsumerStats {
+	return ConsumerStats{
+		DuplicatesSkipped:        c.duplicatesSkipped.Load(),
+		OversizedMessagesDropped: c.oversizedDropped.Load(),
+	}
+}
+
+// BlocklistFilter returns a WithFilter predicate that drops the requests
+// for the given attachment IDs.
+func BlocklistFilter(ids []int) func(TranscriptionRequest) bool {
+	blocked := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		blocked[id] = struct{}{}
+	}
+	return func(request TranscriptionRequest) bool {
+		_, ok := blocked[request.AttachmentID]
+		return !ok
+	}
+}
+
+// clampPriority bounds priority to the range accepted by MainQueue.
+func clampPriority(priority int) uint8 {
+	if priority < 0 {
+		return 0
+	}
+	if priority > MaxPriority {
+		return MaxPriority
+	}
+	return uint8(priority)
 }
 
 // declareConsumerTopology declares exchanges and queues for consuming.
-func declareConsumerTopology(ch *amqp.Channel) error {
+func declareConsumerTopology(ch Channel, topology Topology) error {
 	// Declare main exchange
 	if err := ch.ExchangeDeclare(
-		MainExchange, // name
-		"direct",     // type
-		true,         // durable
-		false,        // auto-deleted
-		false,        // internal
-		false,        // no-wait
-		nil,          // arguments
+		MainExchange,          // name
+		topology.ExchangeType, // type
+		true,                  // durable
+		false,                 // auto-deleted
+		false,                 // internal
+		false,                 // no-wait
+		nil,                   // arguments
 	); err != nil {
 		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	// Declare main queue
+	// Declare main queue as a priority queue
 	if _, err := ch.QueueDeclare(
-		MainQueue, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
+		topology.Queue, // name
+		true,           // durable
+		false,          // delete when unused
+		false,          // exclusive
+		false,          // no-wait
+		amqp.Table{
+			"x-max-priority": int32(MaxPriority),
+		},
 	); err != nil {
 		return fmt.Errorf("failed to declare queue: %w", err)
 	}
 
 	// Bind queue to exchange
 	if err := ch.QueueBind(
-		MainQueue,       // queue name
-		MainRoutingKey,  // routing key
-		MainExchange,    // exchange
-		false,           // no-wait
-		nil,             // arguments
+		topology.Queue,      // queue name
+		topology.BindingKey, // routing key
+		MainExchange,        // exchange
+		false,               // no-wait
+		nil,                 // arguments
 	); err != nil {
 		return fmt.Errorf("failed to bind queue: %w", err)
 	}
@@ -96,57 +332,469 @@ func declareConsumerTopology(ch *amqp.Channel) error {
 	return nil
 }
 
+// subscription is an active basic.consume on the consumer channel.
+type subscription struct {
+	deliveries <-chan amqp.Delivery
+	cancelled  <-chan string
+	closed     <-chan *amqp.Error
+}
+
 // Consume starts consuming messages and returns a channel of Jobs.
+// The returned channel stays open across channel cancellations and
+// reconnections; it is closed only after Close is called.
 func (c *Consumer) Consume() (<-chan Job, error) {
-	msgs, err := c.channel.Consume(
-		c.queue,          // queue
-		"go-orchestrator", // consumer tag
-		false,            // auto-ack (we'll manually ACK)
-		false,            // exclusive
-		false,            // no-local
-		false,            // no-wait
-		nil,              // args
-	)
+	return c.ConsumeWithContext(context.Background())
+}
+
+// ConsumeWithContext is like Consume, but also stops consuming once ctx is
+// done. A delivery that cannot be handed to a reader by then is requeued
+// instead of blocking the consumer goroutine.
+func (c *Consumer) ConsumeWithContext(ctx context.Context) (<-chan Job, error) {
+	sub, err := c.subscribe(c.currentChannel())
 	if err != nil {
-		return nil, fmt.Errorf("failed to start consuming: %w", err)
+		return nil, err
 	}
 
+	context.AfterFunc(ctx, c.cancel)
+
 	jobs := make(chan Job)
+	go c.consumeLoop(sub, jobs)
+
+	slog.Info("Consumer started",
+		slog.String("queue", c.queue),
+		slog.String("exchange_type", c.topology.ExchangeType),
+		slog.String("binding_key", c.topology.BindingKey),
+		slog.String("consumer_tag", c.tag))
+	return jobs, nil
+}
+
+// subscribe starts consuming from the queue on ch.
+func (c *Consumer) subscribe(ch Channel) (subscription, error) {
+	sub := subscription{
+		cancelled: ch.NotifyCancel(make(chan string, 1)),
+		closed:    ch.NotifyClose(make(chan *amqp.Error, 1)),
+	}
+
+	deliveries, err := ch.Consume(
+		c.queue, // queue
+		c.tag,   // consumer tag
+		false,   // auto-ack (we'll manually ACK)
+		false,   // exclusive
+		false,   // no-local
+		false,   // no-wait
+		nil,     // args
+	)
+	if err != nil {
+		return subscription{}, fmt.Errorf("failed to start consuming: %w", err)
+	}
+	sub.deliveries = deliveries
+	return sub, nil
+}
 
-	go func() {
-		defer close(jobs)
+// consumeLoop forwards deliveries to jobs, re-subscribing whenever the
+// broker cancels the consumer or closes the channel.
+func (c *Consumer) consumeLoop(sub subscription, jobs chan<- Job) {
+	defer close(jobs)
 
-		for msg := range msgs {
-			var request TranscriptionRequest
+	for {
+		if !c.forward(sub.deliveries, jobs) {
+			return
+		}
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case tag := <-sub.cancelled:
+			slog.Warn("⚠️  Consumer cancelled by broker", slog.String("consumer_tag", tag))
+		case amqpErr := <-sub.closed:
+			slog.Warn("⚠️  Consumer channel closed", slog.Any("error", amqpErr))
+		default:
+			slog.Warn("⚠️  Consumer deliveries stopped")
+		}
+
+		next, ok := c.reconnect()
+		if !ok {
+			return
+		}
+		sub = next
+	}
+}
+
+// heldDelivery is a delivery waiting for a slot in its priority bucket.
+type heldDelivery struct {
+	msg     amqp.Delivery
+	request TranscriptionRequest
+}
 
-			if err := json.Unmarshal(msg.Body, &request); err != nil {
-				log.Printf("⚠️  Invalid message: %v", err)
-				msg.Nack(false, false)
+// forward converts deliveries into Jobs until deliveries is closed.
+// It returns false if the consumer was closed while forwarding.
+func (c *Consumer) forward(deliveries <-chan amqp.Delivery, jobs chan<- Job) bool {
+	// At most one delivery per full priority bucket, requeued on return
+	held := make(map[uint8]heldDelivery)
+	defer func() {
+		for _, h := range held {
+			h.msg.Nack(false, true)
+		}
+	}()
+
+	var freed <-chan struct{}
+	if c.buckets != nil {
+		freed = c.buckets.freed
+	}
+
+	for {
+		select {
+		case msg, ok := <-deliveries:
+			if !ok {
+				return true
+			}
+			if c.dropOversized(msg) {
+				continue
+			}
+			if c.dedup != nil && c.skipDuplicate(&msg) {
 				continue
 			}
+			request, ok := c.decode(msg)
+			if !ok {
+				continue
+			}
+			if c.filter != nil && !c.filter(request) {
+				slog.Debug("Job filtered out", slog.Int("attachment_id", request.AttachmentID))
+				msg.Ack(false)
+				continue
+			}
+
+			if c.buckets != nil && c.buckets.limited(request.Priority) {
+				if !c.buckets.acquire(request.Priority) {
+					if _, waiting := held[request.Priority]; waiting {
+						go c.requeueLater(msg)
+					} else {
+						held[request.Priority] = heldDelivery{msg: msg, request: request}
+					}
+					continue
+				}
+				c.buckets.track(&msg, request.Priority)
+			}
 
-			// Extract retry count from header if present
-			if retryCount, ok := msg.Headers["x-retry-count"].(int32); ok {
-				request.RetryCount = int(retryCount)
-			} else if retryCount, ok := msg.Headers["x-retry-count"].(int64); ok {
-				request.RetryCount = int(retryCount)
+			if !c.send(msg, request, jobs) {
+				return false
 			}
 
-			jobs <- Job{
-				Request:  request,
-				Delivery: msg,
+		case <-freed:
+			for priority, h := range held {
+				if !c.buckets.acquire(priority) {
+					continue
+				}
+				delete(held, priority)
+				c.buckets.track(&h.msg, priority)
+				if !c.send(h.msg, h.request, jobs) {
+					return false
+				}
 			}
 		}
-	}()
+	}
+}
 
-	log.Printf("[Consumer] Started consuming from queue: %s", c.queue)
-	return jobs, nil
+// deathCount returns how many times the broker dead-lettered a message,
+// from its x-death header. The broker keeps one entry per queue and reason,
+// with the number of deaths in its count field.
+func deathCount(headers amqp.Table) int {
+	deaths, _ := headers["x-death"].([]interface{})
+	total := 0
+	for _, d := range deaths {
+		death, _ := d.(amqp.Table)
+		if count, ok := headerInt(death["count"]); ok {
+			total += count
+		}
+	}
+	return total
+}
+
+// headerInt returns an integer header value, which may be encoded as any
+// AMQP integer type.
+func headerInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+	return 0, false
+}
+
+// dropOversized reports whether msg is larger than the configured limit,
+// archiving it in the dead letter queue if so.
+func (c *Consumer) dropOversized(msg amqp.Delivery) bool {
+	if c.maxBodySize <= 0 || len(msg.Body) <= c.maxBodySize {
+		return false
+	}
+	logger := slog.With(
+		slog.Int("size_bytes", len(msg.Body)),
+		slog.Int("max_bytes", c.maxBodySize),
+		slog.String("message_id", msg.MessageId),
+		slog.String("queue", c.queue))
+	logger.Warn("📦 Message too large, dead-lettering")
+
+	reason := fmt.Sprintf("message body of %d bytes exceeds the limit of %d", len(msg.Body), c.maxBodySize)
+	if c.deadLetters == nil {
+		msg.Nack(false, false)
+	} else if err := c.deadLetters.PublishRejected(msg, ErrCodeMessageTooLarge, reason); err != nil {
+		logger.Error("❌ Dead letter publish failed", slog.Any("error", err))
+		msg.Nack(false, false) // No DLX on the consumed queue, the message is discarded
+	} else {
+		msg.Ack(false)
+	}
+	c.oversizedDropped.Add(1)
+	return true
+}
+
+// decode parses a delivery into a request, filling in the routing key,
+// retry count and priority. Invalid messages are rejected.
+func (c *Consumer) decode(msg amqp.Delivery) (TranscriptionRequest, bool) {
+	var request TranscriptionRequest
+
+	if err := json.Unmarshal(msg.Body, &request); err != nil {
+		slog.Warn("⚠️  Invalid message", slog.Any("error", err))
+		msg.Nack(false, false)
+		return request, false
+	}
+
+	// Retries are dead-lettered back with the original routing key
+	request.RoutingKey = msg.RoutingKey
+
+	// Extract retry count from header if present. Deaths recorded by the
+	// broker also count, so attempts that bypassed PublishRetry are not
+	// retried again
+	if retryCount, ok := headerInt(msg.Headers["x-retry-count"]); ok {
+		request.RetryCount = retryCount
+	}
+	request.RetryCount = max(request.RetryCount, deathCount(msg.Headers))
+
+	// Message priority wins over the body field
+	switch {
+	case msg.Priority > 0:
+		request.Priority = clampPriority(int(msg.Priority))
+	case request.Priority > 0:
+		request.Priority = clampPriority(int(request.Priority))
+	default:
+		request.Priority = c.priority
+	}
+
+	return request, true
+}
+
+// send hands a decoded delivery to jobs, honouring the rate limit.
+// It returns false, requeuing the delivery, if the consumer was closed
+// while waiting.
+func (c *Consumer) send(msg amqp.Delivery, request TranscriptionRequest, jobs chan<- Job) bool {
+	if !c.throttle() {
+		msg.Nack(false, true) // Requeue, consumer is closing
+		return false
+	}
+
+	c.warnIfStale(request)
+
+	ctx, span := telemetry.Start(telemetry.Extract(context.Background(), msg.Headers), "job.receive")
+	span.SetAttribute("attachment_id", request.AttachmentID)
+	span.SetAttribute("retry_count", request.RetryCount)
+	span.SetAttribute("messaging.rabbitmq.routing_key", msg.RoutingKey)
+
+	job := Job{
+		Request:    request,
+		Delivery:   msg,
+		ReceivedAt: time.Now(),
+		EnqueuedAt: sourceTimestamp(msg.Headers),
+		Context:    ctx,
+		Span:       span,
+	}
+	select {
+	case jobs <- job:
+		return true
+	case <-c.ctx.Done():
+		span.End()
+		msg.Nack(false, true) // Requeue, nobody is reading jobs anymore
+		return false
+	}
+}
+
+// warnIfStale logs a warning if request was submitted more than maxJobAge ago.
+func (c *Consumer) warnIfStale(request TranscriptionRequest) {
+	if c.maxJobAge <= 0 || request.SubmittedAt.IsZero() {
+		return
+	}
+	if age := time.Since(request.SubmittedAt); age > c.maxJobAge {
+		slog.Warn("🐢 Stale job received",
+			slog.Int("attachment_id", request.AttachmentID),
+			slog.Time("submitted_at", request.SubmittedAt),
+			slog.Duration("age", age),
+			slog.Int("retry_count", request.RetryCount))
+	}
+}
+
+// sourceTimestamp returns the time in SourceTimestampHeader, or the zero
+// time if it is missing or invalid. Publishers in other languages may send
+// the Unix milliseconds as any integer type or as a string.
+func sourceTimestamp(headers amqp.Table) time.Time {
+	var ms int64
+	switch v := headers[SourceTimestampHeader].(type) {
+	case int64:
+		ms = v
+	case int32:
+		ms = int64(v)
+	case int:
+		ms = int64(v)
+	case float64:
+		ms = int64(v)
+	case string:
+		ms, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// requeueLater returns a delivery whose priority bucket is full to the queue
+// after heldRequeueDelay, freeing its prefetch slot for other priorities.
+func (c *Consumer) requeueLater(msg amqp.Delivery) {
+	select {
+	case <-time.After(heldRequeueDelay):
+	case <-c.ctx.Done():
+	}
+	msg.Nack(false, true)
+}
+
+// readyWaiter is implemented by connections that re-dial on their own,
+// such as *ManagedConnection, and by the Broker of such connections.
+type readyWaiter interface {
+	IsConnected() bool
+	WaitUntilReady(ctx context.Context) error
+}
+
+// reconnect opens a fresh channel, re-declares the topology and subscribes
+// again, backing off until it succeeds or the consumer is closed. While a
+// managed connection is re-dialing it waits for it instead of retrying, so
+// a broker outage does not grow the backoff.
+func (c *Consumer) reconnect() (subscription, bool) {
+	backoff := DefaultReconnectConfig()
+	interval := backoff.InitialInterval
+
+	if old := c.currentChannel(); old != nil && !old.IsClosed() {
+		old.Close()
+	}
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			return subscription{}, false
+		case <-time.After(interval):
+		}
+		if !c.waitForConnection() {
+			return subscription{}, false
+		}
+
+		sub, err := c.resubscribe()
+		if err == nil {
+			slog.Info("📡 Consumer resubscribed", slog.Int("attempts", attempt))
+			return sub, true
+		}
+
+		interval = backoff.next(interval)
+		slog.Warn("⚠️  Consumer resubscribe failed",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", interval),
+			slog.Any("error", err))
+	}
+}
+
+// waitForConnection blocks until the connection can open channels again.
+// It returns false if the consumer was closed while waiting.
+func (c *Consumer) waitForConnection() bool {
+	waiter, ok := c.broker.(readyWaiter)
+	if !ok || waiter.IsConnected() {
+		return true
+	}
+
+	slog.Warn("⏳ Consumer waiting for RabbitMQ connection", slog.String("queue", c.queue))
+	return waiter.WaitUntilReady(c.ctx) == nil
+}
+
+// resubscribe performs a single channel re-open and subscribe attempt.
+func (c *Consumer) resubscribe() (subscription, error) {
+	ch, err := openConsumerChannel(c.broker, c.prefetchCount, c.topology)
+	if err != nil {
+		return subscription{}, err
+	}
+
+	sub, err := c.subscribe(ch)
+	if err != nil {
+		ch.Close()
+		return subscription{}, err
+	}
+
+	c.mu.Lock()
+	c.channel = ch
+	c.mu.Unlock()
+	return sub, nil
+}
+
+// currentChannel returns the channel in use.
+func (c *Consumer) currentChannel() Channel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.channel
+}
+
+// throttle blocks until the rate limiter admits the next job.
+// It returns false if the consumer was closed while waiting.
+func (c *Consumer) throttle() bool {
+	if c.limiter == nil {
+		return true
+	}
+
+	if c.limiter.Allow() {
+		c.throttled.set(false)
+		return true
+	}
+
+	c.throttled.set(true)
+	if c.limiter.Wait(c.ctx) != nil {
+		return false
+	}
+	c.throttled.releaseAfter(c.limiter.Interval())
+	return true
+}
+
+// Throttled reports whether the rate limit is currently holding jobs back.
+// It is cleared once the limiter has refilled a token.
+func (c *Consumer) Throttled() bool {
+	return c.throttled.get()
+}
+
+// OnThrottle registers fn to be called whenever the rate limit starts or
+// stops holding jobs back. fn must not block.
+func (c *Consumer) OnThrottle(fn func(throttled bool)) {
+	c.throttled.setHook(fn)
+}
+
+// IsChannelOpen reports whether the consumer channel is usable.
+func (c *Consumer) IsChannelOpen() bool {
+	ch := c.currentChannel()
+	return ch != nil && !ch.IsClosed()
 }
 
-// Close closes the consumer channel.
+// Close stops consuming and closes the consumer channel.
 func (c *Consumer) Close() error {
-	if c.channel != nil {
-		return c.channel.Close()
+	c.cancel()
+	if ch := c.currentChannel(); ch != nil {
+		return ch.Close()
 	}
 	return nil
 }
@@ -0,0 +1,65 @@
+package rabbitmq_test
+
+import (
+	"testing"
+	"time"
+
+	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/rabbitmq/rabbitmqtest"
+)
+
+// nextThrottle returns the next state reported to an OnThrottle hook,
+// failing the test after testTimeout.
+func nextThrottle(t *testing.T, events <-chan bool) bool {
+	t.Helper()
+	select {
+	case throttled := <-events:
+		return throttled
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a throttle change")
+	}
+	return false
+}
+
+func TestConsumer_RateLimit_ReportsThrottleChanges(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	consumer, err := rabbitmq.NewConsumerFromBroker(broker, rabbitmq.PrefetchConfig{GlobalPrefetch: 2}, rabbitmq.Topology{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	consumer.WithRateLimit(20) // burst of 2, then one job every 50ms
+
+	events := make(chan bool, 8)
+	consumer.OnThrottle(func(throttled bool) { events <- throttled })
+
+	// Two jobs fit in the burst, the other two wait for the limiter
+	for id := 1; id <= 4; id++ {
+		publishRequest(t, broker, rabbitmq.TranscriptionRequest{AttachmentID: id, AudioFilePath: "/tmp/a.wav"})
+	}
+	jobs, err := consumer.Consume()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		job := nextJob(t, jobs)
+		job.Delivery.Ack(false)
+		job.Span.End()
+	}
+
+	if !nextThrottle(t, events) {
+		t.Fatal("first change reported released, want throttled")
+	}
+	// Nothing is held back once the queue is drained and a token refills
+	if nextThrottle(t, events) {
+		t.Fatal("second change reported throttled, want released")
+	}
+	if consumer.Throttled() {
+		t.Error("Throttled() = true after the limit was released")
+	}
+	select {
+	case throttled := <-events:
+		t.Errorf("unexpected extra change to %v", throttled)
+	default:
+	}
+}
@@ -0,0 +1,123 @@
+package rabbitmq
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// dedupCache is a thread-safe LRU of recently ACKed message keys. Keys
+// older than ttl count as unseen; a non-positive ttl keeps them until evicted.
+type dedupCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front is the most recently added
+	entries map[string]*list.Element
+}
+
+// dedupEntry is a key of dedupCache and when it was added.
+type dedupEntry struct {
+	key    string
+	seenAt time.Time
+}
+
+// newDedupCache returns a cache holding up to size keys.
+func newDedupCache(size int, ttl time.Duration) *dedupCache {
+	return &dedupCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// contains reports whether key was added less than ttl ago.
+func (d *dedupCache) contains(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elem, ok := d.entries[key]
+	if !ok {
+		return false
+	}
+	if d.ttl > 0 && time.Since(elem.Value.(*dedupEntry).seenAt) > d.ttl {
+		d.order.Remove(elem)
+		delete(d.entries, key)
+		return false
+	}
+	return true
+}
+
+// add records key as seen now, evicting the least recently added key if
+// the cache is full.
+func (d *dedupCache) add(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if elem, ok := d.entries[key]; ok {
+		elem.Value.(*dedupEntry).seenAt = time.Now()
+		d.order.MoveToFront(elem)
+		return
+	}
+	d.entries[key] = d.order.PushFront(&dedupEntry{key: key, seenAt: time.Now()})
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).key)
+	}
+}
+
+// dedupKey identifies a delivery: its MessageId, or the SHA-256 of its body
+// if the publisher did not set one.
+func dedupKey(msg amqp.Delivery) string {
+	if msg.MessageId != "" {
+		return msg.MessageId
+	}
+	sum := sha256.Sum256(msg.Body)
+	return hex.EncodeToString(sum[:])
+}
+
+// dedupAcknowledger records the key of a delivery once its ACK succeeds.
+// Keys are not recorded when the message is forwarded: a copy redelivered
+// while the original is still being processed, e.g. after a channel loss,
+// must not be ACKed as a duplicate, or the job is lost if the original is
+// then requeued on the dead channel.
+type dedupAcknowledger struct {
+	amqp.Acknowledger
+	cache *dedupCache
+	key   string
+}
+
+// Ack implements amqp.Acknowledger.
+func (a *dedupAcknowledger) Ack(tag uint64, multiple bool) error {
+	if err := a.Acknowledger.Ack(tag, multiple); err != nil {
+		return err
+	}
+	a.cache.add(a.key)
+	return nil
+}
+
+// skipDuplicate reports whether msg is a redelivery of a message already
+// ACKed, ACKing it again if so. Otherwise it makes the ACK of msg record
+// it in the cache.
+func (c *Consumer) skipDuplicate(msg *amqp.Delivery) bool {
+	key := dedupKey(*msg)
+	if msg.Redelivered && c.dedup.contains(key) {
+		slog.Warn("♊ Redelivered message already processed, skipping",
+			slog.String("message_id", msg.MessageId),
+			slog.String("queue", c.queue))
+		msg.Ack(false)
+		c.duplicatesSkipped.Add(1)
+		return true
+	}
+	msg.Acknowledger = &dedupAcknowledger{
+		Acknowledger: msg.Acknowledger,
+		cache:        c.dedup,
+		key:          key,
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+// Package rabbitmq provides the dead letter queue consumer.
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadJob is a job that exhausted its retries, read back from the dead letter queue.
+// For a result that expired before being consumed, Result is set instead of
+// Request and Timestamp is when it expired. For a request rejected before
+// decoding, Rejected is set, Request is empty and the raw body is in
+// Delivery.
+type DeadJob struct {
+	Request   TranscriptionRequest
+	Result    *TranscriptionResult
+	Rejected  bool
+	Error     string
+	Timestamp time.Time
+	Delivery  amqp.Delivery
+}
+
+// expiredResultError is the DeadJob.Error of an expired result.
+const expiredResultError = "result expired before being consumed"
+
+// decodeDeadJob decodes a message of the dead letter queue, either a
+// DeadLetterMessage, an expired result or a rejected request.
+func decodeDeadJob(msg amqp.Delivery) (DeadJob, error) {
+	if msg.RoutingKey == RejectedRequestRoutingKey {
+		reason, _ := msg.Headers[DeadLetterReasonHeader].(string)
+		return DeadJob{
+			Rejected:  true,
+			Error:     reason,
+			Timestamp: msg.Timestamp,
+			Delivery:  msg,
+		}, nil
+	}
+	if msg.RoutingKey == ExpiredResultRoutingKey {
+		var result TranscriptionResult
+		if err := json.Unmarshal(msg.Body, &result); err != nil {
+			return DeadJob{}, err
+		}
+		return DeadJob{
+			Request:   TranscriptionRequest{AttachmentID: result.AttachmentID, ImportBatchID: result.ImportBatchID},
+			Result:    &result,
+			Error:     expiredResultError,
+			Timestamp: deathTime(msg),
+			Delivery:  msg,
+		}, nil
+	}
+
+	var dead DeadLetterMessage
+	if err := json.Unmarshal(msg.Body, &dead); err != nil {
+		return DeadJob{}, err
+	}
+	return DeadJob{
+		Request:   dead.Request,
+		Error:     dead.Error,
+		Timestamp: dead.FailedAt,
+		Delivery:  msg,
+	}, nil
+}
+
+// deathTime returns when the broker dead-lettered msg, from its x-death
+// header, or the zero time if it is missing.
+func deathTime(msg amqp.Delivery) time.Time {
+	deaths, _ := msg.Headers["x-death"].([]interface{})
+	if len(deaths) == 0 {
+		return time.Time{}
+	}
+	death, _ := deaths[0].(amqp.Table)
+	at, _ := death["time"].(time.Time)
+	return at
+}
+
+// DeadJobFilter selects dead jobs to republish. Zero fields match any job.
+type DeadJobFilter struct {
+	FailedAfter     time.Time `json:"failed_after"`
+	FailedBefore    time.Time `json:"failed_before"`
+	MinAttachmentID int       `json:"min_attachment_id"`
+	MaxAttachmentID int       `json:"max_attachment_id"`
+	ErrorContains   string    `json:"error_contains"` // case-sensitive substring of the final error
+}
+
+// Match reports whether job satisfies every set field of f.
+func (f DeadJobFilter) Match(job DeadJob) bool {
+	switch {
+	case !f.FailedAfter.IsZero() && job.Timestamp.Before(f.FailedAfter):
+		return false
+	case !f.FailedBefore.IsZero() && !job.Timestamp.Before(f.FailedBefore):
+		return false
+	case f.MinAttachmentID > 0 && job.Request.AttachmentID < f.MinAttachmentID:
+		return false
+	case f.MaxAttachmentID > 0 && job.Request.AttachmentID > f.MaxAttachmentID:
+		return false
+	case f.ErrorContains != "" && !strings.Contains(job.Error, f.ErrorContains):
+		return false
+	}
+	return true
+}
+
+// DLQConsumer reads archived jobs from the dead letter queue for inspection.
+type DLQConsumer struct {
+	conn         ChannelSource
+	channel      *amqp.Channel
+	exchangeType string // type of MainExchange, selects the republish routing key
+}
+
+// NewDLQConsumer creates a consumer for the dead letter queue.
+func NewDLQConsumer(conn ChannelSource) (*DLQConsumer, error) {
+	channel, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	if err := declareDeadLetterTopology(amqpChannel{channel}); err != nil {
+		channel.Close()
+		return nil, err
+	}
+
+	return &DLQConsumer{
+		conn:         conn,
+		channel:      channel,
+		exchangeType: ExchangeDirect,
+	}, nil
+}
+
+// WithExchangeType sets the type of MainExchange so Republish uses the
+// matching routing key. It defaults to ExchangeDirect.
+func (c *DLQConsumer) WithExchangeType(exchangeType string) *DLQConsumer {
+	if exchangeType != "" {
+		c.exchangeType = exchangeType
+	}
+	return c
+}
+
+// Republish moves the dead jobs accepted by filter back to MainExchange with
+// RetryCount reset to 0, and returns how many were republished. A nil filter
+// accepts every job. Only the messages in the queue when it starts are
+// examined; rejected and unreadable ones, expired results and requests
+// rejected before decoding are left in the queue.
+//
+// Each job is acknowledged only after the broker confirms its republish, so
+// a failure leaves it in the dead letter queue. It stops at the first
+// failure or when ctx is done.
+func (c *DLQConsumer) Republish(ctx context.Context, filter func(DeadJob) bool) (int, error) {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("failed to enable confirm mode: %w", err)
+	}
+
+	queue, err := ch.QueueDeclarePassive(DeadLetterQueue, true, false, false, false, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect dead letter queue: %w", err)
+	}
+
+	// Skipped messages stay unacknowledged so Get does not return them
+	// again, and are requeued once the scan is over
+	var skipped []amqp.Delivery
+	defer func() {
+		for _, msg := range skipped {
+			msg.Nack(false, true)
+		}
+	}()
+
+	republished := 0
+	for i := 0; i < queue.Messages; i++ {
+		if err := ctx.Err(); err != nil {
+			return republished, err
+		}
+
+		msg, ok, err := ch.Get(DeadLetterQueue, false)
+		if err != nil {
+			return republished, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		job, err := decodeDeadJob(msg)
+		if err != nil {
+			slog.Warn("⚠️  Invalid dead letter", slog.Any("error", err))
+			skipped = append(skipped, msg)
+			continue
+		}
+		if job.Result != nil || job.Rejected || (filter != nil && !filter(job)) {
+			skipped = append(skipped, msg)
+			continue
+		}
+
+		if err := c.republish(ctx, ch, job.Request); err != nil {
+			skipped = append(skipped, msg)
+			return republished, fmt.Errorf("failed to republish attachment %d: %w", job.Request.AttachmentID, err)
+		}
+		if err := msg.Ack(false); err != nil {
+			return republished, fmt.Errorf("failed to ack dead letter of attachment %d: %w", job.Request.AttachmentID, err)
+		}
+		republished++
+	}
+
+	slog.Info("♻️  Dead letters republished",
+		slog.Int("republished", republished),
+		slog.Int("skipped", len(skipped)))
+	return republished, nil
+}
+
+// republish publishes request to MainExchange as a first attempt and waits
+// for the broker confirmation.
+func (c *DLQConsumer) republish(ctx context.Context, ch *amqp.Channel, request TranscriptionRequest) error {
+	request.RetryCount = 0
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	routingKey := MainRoutingKey
+	if c.exchangeType == ExchangeTopic {
+		routingKey = RequestRoutingKey(request.Language)
+	}
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(
+		ctx,
+		MainExchange, // exchange
+		routingKey,   // routing key
+		false,        // mandatory
+		false,        // immediate
+		withSourceTimestamp(amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Priority:     request.Priority,
+			Body:         body,
+		}, time.Now()),
+	)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("no confirmation: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected message for %s", MainExchange)
+	}
+	return nil
+}
+
+// Consume starts consuming dead jobs. Callers must Ack or Nack each Delivery.
+func (c *DLQConsumer) Consume() (<-chan DeadJob, error) {
+	msgs, err := c.channel.Consume(
+		DeadLetterQueue, // queue
+		"",              // consumer tag (broker generated)
+		false,           // auto-ack
+		false,           // exclusive
+		false,           // no-local
+		false,           // no-wait
+		nil,             // args
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start consuming dead letters: %w", err)
+	}
+
+	jobs := make(chan DeadJob)
+
+	go func() {
+		defer close(jobs)
+
+		for msg := range msgs {
+			job, err := decodeDeadJob(msg)
+			if err != nil {
+				slog.Warn("⚠️  Invalid dead letter", slog.Any("error", err))
+				msg.Nack(false, false)
+				continue
+			}
+
+			jobs <- job
+		}
+	}()
+
+	return jobs, nil
+}
+
+// Close closes the consumer channel.
+func (c *DLQConsumer) Close() error {
+	if c.channel != nil {
+		return c.channel.Close()
+	}
+	return nil
+}
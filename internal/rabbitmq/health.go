@@ -0,0 +1,106 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Resource types reported in TopologyError.
+const (
+	ResourceExchange = "exchange"
+	ResourceQueue    = "queue"
+)
+
+// TopologyError is an exchange or queue that could not be verified.
+type TopologyError struct {
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+	Err          error  `json:"-"`
+}
+
+// Error implements error.
+func (e TopologyError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.ResourceType, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying AMQP error.
+func (e TopologyError) Unwrap() error {
+	return e.Err
+}
+
+// HealthChecker verifies that the broker topology exists using passive
+// declarations, so nothing is created or published.
+type HealthChecker struct {
+	Conn      ChannelSource
+	Exchanges []string
+	Queues    []string
+}
+
+// NewHealthChecker returns a checker for the topology the orchestrator
+// declares: the main, results, retry and dead letter exchanges and queues,
+// the consumed queues, and the results headers and audit exchanges if enabled.
+func NewHealthChecker(conn ChannelSource, consumerQueues []string, headerExchange bool, auditExchange string) *HealthChecker {
+	exchanges := []string{MainExchange, ResultsExchange, RetryExchange, DeadLetterExchange}
+	if headerExchange {
+		exchanges = append(exchanges, ResultsHeadersExchange)
+	}
+	if auditExchange != "" {
+		exchanges = append(exchanges, auditExchange)
+	}
+
+	queues := append([]string(nil), consumerQueues...)
+	queues = append(queues, ResultsQueue, DeadLetterQueue)
+	for attempt := 1; attempt <= MaxRetries; attempt++ {
+		queues = append(queues, RetryQueueName(attempt))
+	}
+
+	return &HealthChecker{Conn: conn, Exchanges: exchanges, Queues: queues}
+}
+
+// CheckTopology passively declares every exchange and queue and returns
+// one TopologyError per missing resource, or nil if all of them exist.
+// Passive declarations only check existence, not arguments. A failed
+// declaration closes the channel, so a new one is opened for the next check.
+func (h *HealthChecker) CheckTopology(ctx context.Context) []TopologyError {
+	var errs []TopologyError
+	var ch *amqp.Channel
+	defer func() {
+		if ch != nil {
+			ch.Close()
+		}
+	}()
+
+	check := func(resourceType, name string, declare func(*amqp.Channel) error) {
+		if ctx.Err() != nil {
+			errs = append(errs, TopologyError{ResourceType: resourceType, Name: name, Err: ctx.Err()})
+			return
+		}
+		if ch == nil || ch.IsClosed() {
+			var err error
+			if ch, err = h.Conn.Channel(); err != nil {
+				ch = nil
+				errs = append(errs, TopologyError{ResourceType: resourceType, Name: name, Err: fmt.Errorf("failed to open channel: %w", err)})
+				return
+			}
+		}
+		if err := declare(ch); err != nil {
+			errs = append(errs, TopologyError{ResourceType: resourceType, Name: name, Err: err})
+		}
+	}
+
+	for _, name := range h.Exchanges {
+		check(ResourceExchange, name, func(ch *amqp.Channel) error {
+			// Kind and flags are not compared by a passive declaration
+			return ch.ExchangeDeclarePassive(name, amqp.ExchangeDirect, true, false, false, false, nil)
+		})
+	}
+	for _, name := range h.Queues {
+		check(ResourceQueue, name, func(ch *amqp.Channel) error {
+			_, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+			return err
+		})
+	}
+	return errs
+}
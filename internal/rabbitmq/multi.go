@@ -0,0 +1,334 @@
+package rabbitmq
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"whisper-local/internal/ratelimit"
+)
+
+// JobConsumer delivers transcription jobs. It is implemented by Consumer and
+// MultiConsumer.
+type JobConsumer interface {
+	Consume() (<-chan Job, error)
+	ConsumeWithContext(ctx context.Context) (<-chan Job, error)
+	IsChannelOpen() bool
+	Lag(ctx context.Context) (int64, error)
+	Stats() ConsumerStats
+	Throttled() bool
+	OnThrottle(fn func(throttled bool))
+	Close() error
+}
+
+// QueueConfig is a queue consumed by a MultiConsumer.
+type QueueConfig struct {
+	Name       string // Queue declared and bound to MainExchange
+	RoutingKey string // Binding key; the Topology default if empty
+	Weight     int    // Jobs taken from the queue per round; below 1 counts as 1
+}
+
+// MultiConsumer consumes several queues, each on its own channel, and merges
+// their jobs with weighted round-robin: while every queue has jobs waiting,
+// each round takes up to Weight jobs from each queue in order. A queue with
+// nothing waiting is skipped, so weights only matter under backlog.
+type MultiConsumer struct {
+	consumers []*Consumer
+	weights   []int
+	limiter   *ratelimit.Limiter
+	throttled throttleState
+	prefetch  int
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewMultiConsumer creates a consumer for queues, all bound to MainExchange
+// as described by topology. Each queue gets its own channel with
+// prefetch.GlobalPrefetch; priority buckets are enforced per queue.
+func NewMultiConsumer(conn ChannelSource, prefetch PrefetchConfig, topology Topology, queues []QueueConfig) (*MultiConsumer, error) {
+	topology = topology.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &MultiConsumer{
+		weights:  make([]int, len(queues)),
+		prefetch: prefetch.GlobalPrefetch,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	for i, queue := range queues {
+		queueTopology := topology
+		queueTopology.Queue = queue.Name
+		if queue.RoutingKey != "" {
+			queueTopology.BindingKey = queue.RoutingKey
+		}
+
+		consumer, err := newConsumer(AMQPBroker(conn), prefetch, queueTopology)
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		m.consumers = append(m.consumers, consumer)
+		m.weights[i] = max(queue.Weight, 1)
+	}
+	return m, nil
+}
+
+// WithRateLimit limits how fast jobs are forwarded, across all queues, to
+// rps per second. A non-positive rps disables the limit.
+func (m *MultiConsumer) WithRateLimit(rps float64) *MultiConsumer {
+	if rps <= 0 {
+		m.limiter = nil
+		return m
+	}
+	m.limiter = ratelimit.NewLimiter(rps, m.prefetch)
+	slog.Info("Consumer rate limited", slog.Float64("rps", rps), slog.Int("burst", m.prefetch))
+	return m
+}
+
+// WithTagPrefix sets the consumer tag prefix of every queue. It must be
+// called before Consume.
+func (m *MultiConsumer) WithTagPrefix(prefix string) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithTagPrefix(prefix)
+	}
+	return m
+}
+
+// WithDefaultPriority sets the priority assigned to requests whose message
+// carries none.
+func (m *MultiConsumer) WithDefaultPriority(priority int) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithDefaultPriority(priority)
+	}
+	return m
+}
+
+// WithMaxJobAgeWarn enables stale job warnings on every queue; see
+// Consumer.WithMaxJobAgeWarn.
+func (m *MultiConsumer) WithMaxJobAgeWarn(maxAge time.Duration) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithMaxJobAgeWarn(maxAge)
+	}
+	return m
+}
+
+// WithFilter filters the requests of every queue; see Consumer.WithFilter.
+func (m *MultiConsumer) WithFilter(fn func(TranscriptionRequest) bool) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithFilter(fn)
+	}
+	return m
+}
+
+// WithMaxMessageSize sets the body size limit of every queue; see
+// Consumer.WithMaxMessageSize.
+func (m *MultiConsumer) WithMaxMessageSize(maxBytes int, deadLetters *Producer) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithMaxMessageSize(maxBytes, deadLetters)
+	}
+	return m
+}
+
+// WithDeduplication enables deduplication on every queue, each with its own
+// cache; see Consumer.WithDeduplication.
+func (m *MultiConsumer) WithDeduplication(cacheSize int, ttl time.Duration) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithDeduplication(cacheSize, ttl)
+	}
+	return m
+}
+
+// Throttled reports whether the shared rate limit is currently holding jobs
+// back; see Consumer.Throttled.
+func (m *MultiConsumer) Throttled() bool {
+	return m.throttled.get()
+}
+
+// OnThrottle registers fn to be called whenever the shared rate limit starts
+// or stops holding jobs back; see Consumer.OnThrottle.
+func (m *MultiConsumer) OnThrottle(fn func(throttled bool)) {
+	m.throttled.setHook(fn)
+}
+
+// Stats returns the consumer counters summed across all queues.
+func (m *MultiConsumer) Stats() ConsumerStats {
+	var stats ConsumerStats
+	for _, c := range m.consumers {
+		consumerStats := c.Stats()
+		stats.DuplicatesSkipped += consumerStats.DuplicatesSkipped
+		stats.OversizedMessagesDropped += consumerStats.OversizedMessagesDropped
+	}
+	return stats
+}
+
+// WithManagementAPI enables Lag for every queue; see Consumer.WithManagementAPI.
+func (m *MultiConsumer) WithManagementAPI(baseURL, vhost string, cacheTTL time.Duration) *MultiConsumer {
+	for _, c := range m.consumers {
+		c.WithManagementAPI(baseURL, vhost, cacheTTL)
+	}
+	return m
+}
+
+// Lag returns the messages ready for delivery across all queues.
+func (m *MultiConsumer) Lag(ctx context.Context) (int64, error) {
+	var total int64
+	for _, c := range m.consumers {
+		lag, err := c.Lag(ctx)
+		if err != nil {
+			return 0, err
+		}
+		total += lag
+	}
+	return total, nil
+}
+
+// Consume starts consuming every queue and returns the merged jobs.
+func (m *MultiConsumer) Consume() (<-chan Job, error) {
+	return m.ConsumeWithContext(context.Background())
+}
+
+// ConsumeWithContext is like Consume, but also stops consuming once ctx is
+// done. Jobs not handed to a reader by then are requeued.
+func (m *MultiConsumer) ConsumeWithContext(ctx context.Context) (<-chan Job, error) {
+	sources := make([]<-chan Job, len(m.consumers))
+	for i, c := range m.consumers {
+		jobs, err := c.ConsumeWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = jobs
+	}
+
+	context.AfterFunc(ctx, m.cancel)
+
+	jobs := make(chan Job)
+	go m.merge(sources, jobs)
+	return jobs, nil
+}
+
+// merge forwards jobs from sources with weighted round-robin until every
+// source is closed or the consumer is closed. A closed source is set to nil.
+func (m *MultiConsumer) merge(sources []<-chan Job, jobs chan<- Job) {
+	defer close(jobs)
+
+	open := len(sources)
+	for open > 0 {
+		took := false
+		for i := range sources {
+		drain:
+			for n := 0; n < m.weights[i] && sources[i] != nil; n++ {
+				select {
+				case job, ok := <-sources[i]:
+					if !ok {
+						sources[i] = nil
+						open--
+						break drain
+					}
+					if !m.send(job, jobs) {
+						return
+					}
+					took = true
+				default:
+					break drain
+				}
+			}
+		}
+		if took || open == 0 {
+			continue
+		}
+
+		// Every queue is empty: wait for the next job from any of them
+		i, job, ok := m.next(sources)
+		switch {
+		case i < 0:
+			return
+		case !ok:
+			sources[i] = nil
+			open--
+		case !m.send(job, jobs):
+			return
+		}
+	}
+}
+
+// next blocks until a source yields a job or is closed, returning its index,
+// or -1 if the consumer was closed first.
+func (m *MultiConsumer) next(sources []<-chan Job) (int, Job, bool) {
+	cases := make([]reflect.SelectCase, 0, len(sources)+1)
+	indexes := make([]int, 0, len(sources))
+	for i, source := range sources {
+		if source != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(source)})
+			indexes = append(indexes, i)
+		}
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.ctx.Done())})
+
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == len(indexes) {
+		return -1, Job{}, false
+	}
+	if !ok {
+		return indexes[chosen], Job{}, false
+	}
+	return indexes[chosen], value.Interface().(Job), true
+}
+
+// send hands job to jobs, honouring the rate limit. It returns false,
+// requeuing the job, if the consumer was closed first.
+func (m *MultiConsumer) send(job Job, jobs chan<- Job) bool {
+	if m.throttle() {
+		select {
+		case jobs <- job:
+			return true
+		case <-m.ctx.Done():
+		}
+	}
+
+	job.Span.End()
+	job.Delivery.Nack(false, true) // Requeue, nobody is reading jobs anymore
+	return false
+}
+
+// throttle blocks until the shared rate limiter admits the next job. It
+// returns false if the consumer was closed while waiting.
+func (m *MultiConsumer) throttle() bool {
+	if m.limiter == nil {
+		return true
+	}
+
+	if m.limiter.Allow() {
+		m.throttled.set(false)
+		return true
+	}
+
+	m.throttled.set(true)
+	if m.limiter.Wait(m.ctx) != nil {
+		return false
+	}
+	m.throttled.releaseAfter(m.limiter.Interval())
+	return true
+}
+
+// IsChannelOpen reports whether the channel of every queue is usable.
+func (m *MultiConsumer) IsChannelOpen() bool {
+	for _, c := range m.consumers {
+		if !c.IsChannelOpen() {
+			return false
+		}
+	}
+	return true
+}
+
+// Close stops consuming and closes the channel of every queue.
+func (m *MultiConsumer) Close() error {
+	m.cancel()
+	var firstErr error
+	for _, c := range m.consumers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
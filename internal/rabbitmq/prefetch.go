@@ -0,0 +1,125 @@
+package rabbitmq
+
+import (
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// heldRequeueDelay is how long a delivery whose priority bucket is full, and
+// that cannot be held, waits before it is requeued. Requeuing at once would
+// make the broker redeliver it in a tight loop while the bucket stays full.
+const heldRequeueDelay = time.Second
+
+// PrefetchConfig controls how many deliveries the consumer keeps in flight.
+type PrefetchConfig struct {
+	// GlobalPrefetch is the QoS prefetch count of the consumer channel.
+	GlobalPrefetch int
+
+	// PerPriorityBuckets caps the jobs in flight per priority. Priorities
+	// without a bucket are only bounded by GlobalPrefetch. When a bucket is
+	// full one delivery of that priority is held until a job of the same
+	// priority finishes; further ones are requeued after heldRequeueDelay,
+	// so a burst of low priority jobs cannot fill the prefetch window.
+	PerPriorityBuckets map[uint8]int
+}
+
+// priorityBuckets counts jobs in flight per priority.
+type priorityBuckets struct {
+	mu       sync.Mutex
+	limits   map[uint8]int
+	inFlight map[uint8]int
+	freed    chan struct{} // signalled, coalesced, whenever a slot is released
+}
+
+// newPriorityBuckets returns the buckets for limits, or nil if there are none.
+func newPriorityBuckets(limits map[uint8]int) *priorityBuckets {
+	if len(limits) == 0 {
+		return nil
+	}
+	return &priorityBuckets{
+		limits:   limits,
+		inFlight: make(map[uint8]int, len(limits)),
+		freed:    make(chan struct{}, 1),
+	}
+}
+
+// acquire takes a slot for priority. It reports false if the bucket is full.
+// Priorities without a bucket always succeed and are not counted.
+func (b *priorityBuckets) acquire(priority uint8) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	limit, ok := b.limits[priority]
+	if !ok {
+		return true
+	}
+	if b.inFlight[priority] >= limit {
+		return false
+	}
+	b.inFlight[priority]++
+	return true
+}
+
+// release frees a slot taken by acquire.
+func (b *priorityBuckets) release(priority uint8) {
+	b.mu.Lock()
+	if b.inFlight[priority] > 0 {
+		b.inFlight[priority]--
+	}
+	b.mu.Unlock()
+
+	select {
+	case b.freed <- struct{}{}:
+	default:
+	}
+}
+
+// limited reports whether priority has a bucket.
+func (b *priorityBuckets) limited(priority uint8) bool {
+	_, ok := b.limits[priority]
+	return ok
+}
+
+// bucketAcknowledger releases the bucket slot of a delivery once it is
+// acknowledged, rejected or requeued.
+type bucketAcknowledger struct {
+	amqp.Acknowledger
+	buckets  *priorityBuckets
+	priority uint8
+	once     sync.Once
+}
+
+// track wraps the acknowledger of msg so its slot is released when the job
+// finishes.
+func (b *priorityBuckets) track(msg *amqp.Delivery, priority uint8) {
+	msg.Acknowledger = &bucketAcknowledger{
+		Acknowledger: msg.Acknowledger,
+		buckets:      b,
+		priority:     priority,
+	}
+}
+
+// Ack implements amqp.Acknowledger.
+func (a *bucketAcknowledger) Ack(tag uint64, multiple bool) error {
+	defer a.done()
+	return a.Acknowledger.Ack(tag, multiple)
+}
+
+// Nack implements amqp.Acknowledger.
+func (a *bucketAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	defer a.done()
+	return a.Acknowledger.Nack(tag, multiple, requeue)
+}
+
+// Reject implements amqp.Acknowledger.
+func (a *bucketAcknowledger) Reject(tag uint64, requeue bool) error {
+	defer a.done()
+	return a.Acknowledger.Reject(tag, requeue)
+}
+
+// done releases the slot, once.
+func (a *bucketAcknowledger) done() {
+	a.once.Do(func() { a.buckets.release(a.priority) })
+}
@@ -2,11 +2,19 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+
+	"whisper-local/internal/telemetry"
 )
 
 const (
@@ -15,49 +23,178 @@ const (
 	ResultsExchange   = "whisper_results_exchange"
 	ResultsRoutingKey = "transcription.result"
 
-	// Retry queue configuration
-	RetryExchange   = "whisper_retry_exchange"
-	RetryRoutingKey = "transcription.retry"
-	RetryQueue      = "whisper_retry_queue"
-	RetryTTLMs      = 5000 // 5 seconds delay before retry
+	// Retry queue configuration: one delay queue per attempt,
+	// named whisper_retry_<n> and bound with transcription.retry.<n>
+	RetryExchange    = "whisper_retry_exchange"
+	RetryRoutingKey  = "transcription.retry"
+	RetryQueuePrefix = "whisper_retry_"
+
+	// Dead letter configuration for jobs that exhausted their retries
+	DeadLetterExchange   = "whisper_dlx_exchange"
+	DeadLetterQueue      = "whisper_dead_letter"
+	DeadLetterRoutingKey = "transcription.dead"
+
+	// ExpiredResultRoutingKey routes results that expired in ResultsQueue
+	// to the dead letter queue
+	ExpiredResultRoutingKey = "transcription.result.expired"
+
+	// RejectedRequestRoutingKey routes to the dead letter queue the raw
+	// body of requests rejected before decoding, with DeadLetterCodeHeader
+	// and DeadLetterReasonHeader saying why
+	RejectedRequestRoutingKey = "transcription.request.rejected"
+	DeadLetterCodeHeader      = "x-error-code"
+	DeadLetterReasonHeader    = "x-error"
 
 	// Max retries (2 retries = 3 total attempts)
 	MaxRetries = 2
+
+	// RetryAttemptHeader selects the retry queue when MainExchange is a topic
+	// exchange, since the routing key must stay the original one
+	RetryAttemptHeader = "x-retry-attempt"
+
+	// SourceTimestampHeader carries the publish time in Unix milliseconds,
+	// read back by the consumer into Job.EnqueuedAt
+	SourceTimestampHeader = "x-source-timestamp"
+
+	// Headers set on every result so consumers can filter without decoding
+	// the body; LanguageHeader is omitted when the request had no language
+	ModelHeader    = "x-whisper-model"
+	LanguageHeader = "x-whisper-language"
+
+	// ResultsHeadersExchange receives a copy of every result when the
+	// header exchange is enabled; subscribers bind their own queues to it
+	// with x-match on ModelHeader and LanguageHeader
+	ResultsHeadersExchange = "whisper_results_headers"
+
+	// Headers added to the copy of each result sent to the audit exchange:
+	// the publish time in Unix milliseconds and the publishing host
+	AuditTimestampHeader = "x-audit-timestamp"
+	AuditHostnameHeader  = "x-orchestrator-hostname"
 )
 
-// Producer handles publishing messages to RabbitMQ.
+// DefaultConfirmTimeout is used when ProducerOptions.ConfirmTimeout is not set.
+const DefaultConfirmTimeout = 5 * time.Second
+
+// ProducerOptions configures a Producer.
+type ProducerOptions struct {
+	Model          string        // Whisper model reported in results
+	Retry          RetryPolicy   // Delay applied to each retry attempt
+	ConfirmTimeout time.Duration // Max wait for a broker publish confirmation
+	ExchangeType   string        // Type of MainExchange, ExchangeDirect if empty
+
+	// MaxMessageSize caps message bodies in bytes; zero disables the limit.
+	// Larger results are split into chunks, or truncated if TruncateOnOversize.
+	MaxMessageSize     int
+	TruncateOnOversize bool
+
+	// ResultTTLMs is the expiration of published results in milliseconds;
+	// zero disables it. Expired results are moved to the dead letter queue.
+	ResultTTLMs int
+
+	// HeaderExchange also publishes every result to ResultsHeadersExchange.
+	HeaderExchange bool
+
+	// AuditExchange, if set, is a durable fanout exchange that receives a
+	// copy of every result, successful or not; empty disables it.
+	AuditExchange string
+}
+
+// Producer handles publishing messages to RabbitMQ. Results, retries and
+// errors are published on separate channels, so a channel error while
+// publishing one kind of message does not break the others.
 type Producer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	model   string
+	broker         Broker
+	resultCh       *producerChannel // successful results
+	retryCh        *producerChannel // retries
+	errorCh        *producerChannel // error results and dead letters
+	modelMu        sync.RWMutex
+	model          string
+	retry          RetryPolicy
+	confirmTimeout time.Duration
+	exchangeType   string
+	maxMessageSize int
+	truncate       bool
+	resultTTLMs    int
+	headerExchange bool
+	auditExchange  string
+	hostname       string // AuditHostnameHeader value
 }
 
-// NewProducer creates a new RabbitMQ producer.
-func NewProducer(conn *amqp.Connection, whisperModel string) (*Producer, error) {
-	channel, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open channel: %w", err)
+// ProducerStats holds producer counters.
+type ProducerStats struct {
+	ChannelReopenCount int64 `json:"channel_reopen_count"` // across all channels
+}
+
+// NewProducer creates a new RabbitMQ producer with publisher confirms enabled.
+func NewProducer(conn ChannelSource, opts ProducerOptions) (*Producer, error) {
+	return NewProducerFromBroker(AMQPBroker(conn), opts)
+}
+
+// NewProducerFromBroker is NewProducer for channels opened by broker, such
+// as a rabbitmqtest.MockBroker.
+func NewProducerFromBroker(broker Broker, opts ProducerOptions) (*Producer, error) {
+	if opts.ConfirmTimeout <= 0 {
+		opts.ConfirmTimeout = DefaultConfirmTimeout
+	}
+	if opts.ExchangeType == "" {
+		opts.ExchangeType = ExchangeDirect
 	}
 
-	// Declare topology
-	if err := declareProducerTopology(channel); err != nil {
-		channel.Close()
-		return nil, err
+	p := &Producer{
+		broker: broker,
+		resultCh: newProducerChannel("result", func(ch Channel) error {
+			return declareResultTopology(ch, opts.HeaderExchange, opts.AuditExchange)
+		}),
+		retryCh: newProducerChannel("retry", func(ch Channel) error {
+			return declareRetryTopology(ch, opts.ExchangeType)
+		}),
+		errorCh: newProducerChannel("error", func(ch Channel) error {
+			return declareErrorTopology(ch, opts.HeaderExchange, opts.AuditExchange)
+		}),
+		model:          opts.Model,
+		retry:          opts.Retry,
+		confirmTimeout: opts.ConfirmTimeout,
+		exchangeType:   opts.ExchangeType,
+		maxMessageSize: opts.MaxMessageSize,
+		truncate:       opts.TruncateOnOversize,
+		resultTTLMs:    opts.ResultTTLMs,
+		headerExchange: opts.HeaderExchange,
+		auditExchange:  opts.AuditExchange,
+	}
+	if p.auditExchange != "" {
+		if p.hostname, _ = os.Hostname(); p.hostname == "" {
+			p.hostname = "unknown"
+		}
+	}
+	for _, ch := range p.channels() {
+		if err := ch.open(broker); err != nil {
+			p.Close()
+			return nil, err
+		}
 	}
 
-	log.Printf("[Producer] Connected and ready")
+	slog.Info("Producer connected and ready")
+	return p, nil
+}
 
-	return &Producer{
-		conn:    conn,
-		channel: channel,
-		model:   whisperModel,
-	}, nil
+// channels returns the channels of the producer.
+func (p *Producer) channels() []*producerChannel {
+	return []*producerChannel{p.resultCh, p.retryCh, p.errorCh}
 }
 
-// declareProducerTopology declares exchanges and queues for producing.
-func declareProducerTopology(ch *amqp.Channel) error {
-	// === Results topology ===
+// Stats returns producer counters.
+func (p *Producer) Stats() ProducerStats {
+	var stats ProducerStats
+	for _, ch := range p.channels() {
+		stats.ChannelReopenCount += ch.reopens.Load()
+	}
+	return stats
+}
 
+// declareResultTopology declares the exchange and queue for results,
+// ResultsHeadersExchange if headerExchange is set, and auditExchange if it
+// is not empty.
+func declareResultTopology(ch Channel, headerExchange bool, auditExchange string) error {
 	// Declare results exchange
 	if err := ch.ExchangeDeclare(
 		ResultsExchange, // name
@@ -71,14 +208,17 @@ func declareProducerTopology(ch *amqp.Channel) error {
 		return fmt.Errorf("failed to declare results exchange: %w", err)
 	}
 
-	// Declare results queue
+	// Declare results queue; expired results go to the dead letter queue
 	if _, err := ch.QueueDeclare(
 		ResultsQueue, // name
 		true,         // durable
 		false,        // delete when unused
 		false,        // exclusive
 		false,        // no-wait
-		nil,          // arguments
+		amqp.Table{
+			"x-dead-letter-exchange":    DeadLetterExchange,
+			"x-dead-letter-routing-key": ExpiredResultRoutingKey,
+		},
 	); err != nil {
 		return fmt.Errorf("failed to declare results queue: %w", err)
 	}
@@ -94,12 +234,56 @@ func declareProducerTopology(ch *amqp.Channel) error {
 		return fmt.Errorf("failed to bind results queue: %w", err)
 	}
 
-	// === Retry topology ===
+	// Declare the headers exchange; it has no queues of its own
+	if headerExchange {
+		if err := ch.ExchangeDeclare(
+			ResultsHeadersExchange, // name
+			"headers",              // type
+			true,                   // durable
+			false,                  // auto-deleted
+			false,                  // internal
+			false,                  // no-wait
+			nil,                    // arguments
+		); err != nil {
+			return fmt.Errorf("failed to declare results headers exchange: %w", err)
+		}
+	}
+
+	// Declare the audit exchange; compliance binds its own queues to it
+	if auditExchange != "" {
+		if err := ch.ExchangeDeclare(
+			auditExchange, // name
+			"fanout",      // type
+			true,          // durable
+			false,         // auto-deleted
+			false,         // internal
+			false,         // no-wait
+			nil,           // arguments
+		); err != nil {
+			return fmt.Errorf("failed to declare audit exchange: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// declareRetryTopology declares the exchange and per-attempt delay queues
+// for retries. With a topic MainExchange, retries keep their original
+// routing key: the retry exchange is a headers exchange matching
+// RetryAttemptHeader and the retry queues dead-letter without overriding
+// the routing key. The queues declare no TTL: the delay is the expiration
+// of each message, so their arguments do not depend on the RetryPolicy.
+func declareRetryTopology(ch Channel, exchangeType string) error {
+	topic := exchangeType == ExchangeTopic
 
 	// Declare retry exchange
+	retryType := "direct"
+	if topic {
+		retryType = "headers"
+	}
 	if err := ch.ExchangeDeclare(
 		RetryExchange, // name
-		"direct",      // type
+		retryType,     // type
 		true,          // durable
 		false,         // auto-deleted
 		false,         // internal
@@ -109,83 +293,480 @@ func declareProducerTopology(ch *amqp.Channel) error {
 		return fmt.Errorf("failed to declare retry exchange: %w", err)
 	}
 
-	// Declare retry queue with TTL and DLX back to main queue
-	if _, err := ch.QueueDeclare(
-		RetryQueue, // name
-		true,       // durable
-		false,      // delete when unused
-		false,      // exclusive
-		false,      // no-wait
-		amqp.Table{
-			"x-message-ttl":             int32(RetryTTLMs),
-			"x-dead-letter-exchange":    MainExchange,
-			"x-dead-letter-routing-key": MainRoutingKey,
-		},
+	// Declare one retry queue per attempt with DLX back to main queue
+	for attempt := 1; attempt <= MaxRetries; attempt++ {
+		queue := RetryQueueName(attempt)
+
+		args := amqp.Table{
+			"x-dead-letter-exchange": MainExchange,
+		}
+		bindingKey, bindingArgs := RetryRoutingKeyFor(attempt), amqp.Table(nil)
+		if topic {
+			bindingKey = ""
+			// Plain all/any ignore headers starting with x-
+			bindingArgs = amqp.Table{"x-match": "all-with-x", RetryAttemptHeader: int32(attempt)}
+		} else {
+			args["x-dead-letter-routing-key"] = MainRoutingKey
+		}
+
+		if _, err := ch.QueueDeclare(
+			queue, // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			args,  // arguments
+		); err != nil {
+			return fmt.Errorf("failed to declare retry queue %s: %w", queue, err)
+		}
+
+		if err := ch.QueueBind(
+			queue,         // queue name
+			bindingKey,    // routing key
+			RetryExchange, // exchange
+			false,         // no-wait
+			bindingArgs,   // arguments
+		); err != nil {
+			return fmt.Errorf("failed to bind retry queue %s: %w", queue, err)
+		}
+	}
+
+	return nil
+}
+
+// declareErrorTopology declares what the error channel publishes to: the
+// results topology for error results and the dead letter topology.
+func declareErrorTopology(ch Channel, headerExchange bool, auditExchange string) error {
+	if err := declareResultTopology(ch, headerExchange, auditExchange); err != nil {
+		return err
+	}
+	return declareDeadLetterTopology(ch)
+}
+
+// declareDeadLetterTopology declares the exchange and queue for dead jobs.
+func declareDeadLetterTopology(ch Channel) error {
+	// Declare dead letter exchange
+	if err := ch.ExchangeDeclare(
+		DeadLetterExchange, // name
+		"direct",           // type
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
 	); err != nil {
-		return fmt.Errorf("failed to declare retry queue: %w", err)
+		return fmt.Errorf("failed to declare dead letter exchange: %w", err)
 	}
 
-	// Bind retry queue
-	if err := ch.QueueBind(
-		RetryQueue,      // queue name
-		RetryRoutingKey, // routing key
-		RetryExchange,   // exchange
+	// Declare dead letter queue
+	if _, err := ch.QueueDeclare(
+		DeadLetterQueue, // name
+		true,            // durable
+		false,           // delete when unused
+		false,           // exclusive
 		false,           // no-wait
 		nil,             // arguments
 	); err != nil {
-		return fmt.Errorf("failed to bind retry queue: %w", err)
+		return fmt.Errorf("failed to declare dead letter queue: %w", err)
+	}
+
+	// Bind dead letter queue, for dead jobs, expired results and rejected requests
+	for _, routingKey := range []string{DeadLetterRoutingKey, ExpiredResultRoutingKey, RejectedRequestRoutingKey} {
+		if err := ch.QueueBind(
+			DeadLetterQueue,    // queue name
+			routingKey,         // routing key
+			DeadLetterExchange, // exchange
+			false,              // no-wait
+			nil,                // arguments
+		); err != nil {
+			return fmt.Errorf("failed to bind dead letter queue: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// Model returns the Whisper model reported in results.
+func (p *Producer) Model() string {
+	p.modelMu.RLock()
+	defer p.modelMu.RUnlock()
+	return p.model
+}
+
+// SetModel changes the Whisper model reported in results.
+func (p *Producer) SetModel(model string) {
+	p.modelMu.Lock()
+	defer p.modelMu.Unlock()
+	p.model = model
+}
+
 // PublishResult publishes a transcription result to the results queue.
+// A result larger than the max message size is split into chunks, or
+// truncated if the producer is configured to. Chunks share the expiration
+// of the result. Error results are published on the error channel.
 func (p *Producer) PublishResult(result TranscriptionResult) error {
+	return p.PublishResultWithContext(context.Background(), result)
+}
+
+// PublishResultWithContext is like PublishResult, but gives up waiting for
+// the broker once ctx is done, and never later than the confirm timeout.
+func (p *Producer) PublishResultWithContext(ctx context.Context, result TranscriptionResult) error {
+	bodies, err := p.encodeResult(result)
+	if err != nil {
+		return err
+	}
+
+	ch := p.resultCh
+	if !result.Success {
+		ch = p.errorCh
+	}
+	expiration := p.resultExpiration(result, time.Now())
+	headers := resultHeaders(result)
+	for i, body := range bodies {
+		if err := p.publishResultBody(ctx, ch, body, expiration, headers); err != nil {
+			if len(bodies) > 1 {
+				return fmt.Errorf("chunk %d/%d: %w", i+1, len(bodies), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchFailure is a result of a batch that was not published.
+type BatchFailure struct {
+	AttachmentID int
+	Err          error
+}
+
+// BatchPublishError lists the results of PublishResultBatch that were not
+// published, so callers can retry only those.
+type BatchPublishError struct {
+	Total    int
+	Failures []BatchFailure
+}
+
+// Error implements the error interface.
+func (e *BatchPublishError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = fmt.Sprintf("attachment %d: %v", f.AttachmentID, f.Err)
+	}
+	return fmt.Sprintf("failed to publish %d of %d results: %s", len(e.Failures), e.Total, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the error of each failure.
+func (e *BatchPublishError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// AttachmentIDs returns the attachment IDs of the failed results.
+func (e *BatchPublishError) AttachmentIDs() []int {
+	ids := make([]int, len(e.Failures))
+	for i, f := range e.Failures {
+		ids[i] = f.AttachmentID
+	}
+	return ids
+}
+
+// PublishResultBatch publishes results without waiting for each broker
+// confirmation: every message is published first and the confirmations are
+// collected once at the end, within a single confirm timeout. Results that
+// could not be published are reported in a *BatchPublishError.
+func (p *Producer) PublishResultBatch(results []TranscriptionResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	failed := make(map[int]error, len(results)) // result index -> first error
+	fail := func(i int, err error) {
+		if _, ok := failed[i]; !ok {
+			failed[i] = err
+		}
+	}
+
+	ch, err := p.resultCh.ensure(p.broker)
+	if err != nil {
+		for i := range results {
+			fail(i, err)
+		}
+		return batchError(results, failed)
+	}
+
+	// Chunks of a split result share the result's index
+	type pendingConfirm struct {
+		confirm Confirmation
+		result  int
+	}
+	pending := make([]pendingConfirm, 0, len(results))
+
+	for i, result := range results {
+		bodies, err := p.encodeResult(result)
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+
+		expiration := p.resultExpiration(result, time.Now())
+		headers := resultHeaders(result)
+		for _, body := range bodies {
+			msg := withSourceTimestamp(resultPublishing(body, expiration, headers), time.Now())
+			confirm, err := ch.Publish(
+				context.Background(),
+				ResultsExchange,   // exchange
+				ResultsRoutingKey, // routing key
+				false,             // mandatory
+				false,             // immediate
+				msg,
+			)
+			if err != nil {
+				fail(i, fmt.Errorf("failed to publish result: %w", err))
+				break
+			}
+			pending = append(pending, pendingConfirm{confirm: confirm, result: i})
+
+			// The copies are best effort, their confirmations are not awaited
+			if p.headerExchange {
+				if _, err := ch.Publish(context.Background(), ResultsHeadersExchange, "", false, false, msg); err != nil {
+					slog.Warn("⚠️  Failed to publish result to headers exchange",
+						slog.Int("attachment_id", result.AttachmentID),
+						slog.Any("error", err))
+				}
+			}
+			if p.auditExchange != "" {
+				if _, err := ch.Publish(context.Background(), p.auditExchange, "", false, false, p.auditPublishing(msg, time.Now())); err != nil {
+					slog.Warn("⚠️  Failed to publish result to audit exchange",
+						slog.Int("attachment_id", result.AttachmentID),
+						slog.Any("error", err))
+				}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.confirmTimeout)
+	defer cancel()
+
+	for _, pc := range pending {
+		i := pc.result
+		acked, err := pc.confirm.WaitContext(ctx)
+		switch {
+		case err != nil:
+			fail(i, fmt.Errorf("no confirmation after %v: %w", p.confirmTimeout, err))
+		case !acked:
+			fail(i, fmt.Errorf("broker rejected message for %s", ResultsExchange))
+		}
+	}
+
+	if len(failed) > 0 {
+		slog.Warn("⚠️  Result batch partially published",
+			slog.Int("results", len(results)),
+			slog.Int("failed", len(failed)))
+		return batchError(results, failed)
+	}
+	return nil
+}
+
+// batchError builds the *BatchPublishError for the failed result indexes,
+// in batch order.
+func batchError(results []TranscriptionResult, failed map[int]error) error {
+	batchErr := &BatchPublishError{Total: len(results)}
+	for i, result := range results {
+		if err, ok := failed[i]; ok {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{AttachmentID: result.AttachmentID, Err: err})
+		}
+	}
+	return batchErr
+}
+
+// encodeResult returns the message bodies for result: a single body, or
+// the chunks or truncation of a result above the max message size.
+func (p *Producer) encodeResult(result TranscriptionResult) ([][]byte, error) {
 	body, err := json.Marshal(result)
 	if err != nil {
-		return fmt.Errorf("failed to marshal result: %w", err)
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	if p.maxMessageSize > 0 && len(body) > p.maxMessageSize {
+		return p.encodeOversized(result, len(body))
+	}
+	return [][]byte{body}, nil
+}
+
+// encodeOversized encodes a result whose encoding is size bytes, above the
+// max message size.
+func (p *Producer) encodeOversized(result TranscriptionResult, size int) ([][]byte, error) {
+	if p.truncate {
+		truncated, err := truncateResult(result, p.maxMessageSize)
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn("⚠️  Result truncated",
+			slog.Int("attachment_id", result.AttachmentID),
+			slog.Int("size", size),
+			slog.Int("max_size", p.maxMessageSize))
+
+		body, err := json.Marshal(truncated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+		return [][]byte{body}, nil
+	}
+
+	chunks, err := splitResult(result, p.maxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+	slog.Warn("⚠️  Result split into chunks",
+		slog.Int("attachment_id", result.AttachmentID),
+		slog.Int("size", size),
+		slog.Int("chunks", len(chunks)))
+
+	bodies := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		if bodies[i], err = json.Marshal(chunk); err != nil {
+			return nil, fmt.Errorf("failed to marshal result chunk: %w", err)
+		}
 	}
+	return bodies, nil
+}
 
-	err = p.channel.Publish(
+// publishResultBody publishes an encoded result to the results exchange on
+// ch, and copies to ResultsHeadersExchange and the audit exchange if
+// enabled. The result counts as published once the results exchange
+// confirms it: a failed copy is only logged, since requeueing the job would
+// duplicate the result.
+func (p *Producer) publishResultBody(ctx context.Context, ch *producerChannel, body []byte, expiration string, headers amqp.Table) error {
+	msg := resultPublishing(body, expiration, headers)
+	err := p.publishWithTimestamp(
+		ctx,
+		ch,
 		ResultsExchange,   // exchange
 		ResultsRoutingKey, // routing key
-		false,             // mandatory
-		false,             // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			DeliveryMode: amqp.Persistent,
-			Body:         body,
-		},
+		msg,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to publish result: %w", err)
 	}
 
+	if p.headerExchange {
+		if err := p.publishWithTimestamp(ctx, ch, ResultsHeadersExchange, "", msg); err != nil {
+			slog.Warn("⚠️  Failed to publish result to headers exchange", slog.Any("error", err))
+		}
+	}
+	if p.auditExchange != "" {
+		if err := p.publishWithTimestamp(ctx, ch, p.auditExchange, "", p.auditPublishing(msg, time.Now())); err != nil {
+			slog.Warn("⚠️  Failed to publish result to audit exchange", slog.Any("error", err))
+		}
+	}
+
 	return nil
 }
 
-// PublishRetry publishes a message to the retry queue.
+// auditPublishing returns the audit copy of a result message published at
+// now: the same body with the audit headers added and no expiration, since
+// the audit trail must keep every result.
+func (p *Producer) auditPublishing(msg amqp.Publishing, now time.Time) amqp.Publishing {
+	headers := make(amqp.Table, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[AuditTimestampHeader] = now.UnixMilli()
+	headers[AuditHostnameHeader] = p.hostname
+
+	msg.Headers = headers
+	msg.Expiration = ""
+	return msg
+}
+
+// resultPublishing wraps an encoded result in a persistent JSON message
+// with headers that expires after expiration milliseconds, or never if it
+// is empty.
+func resultPublishing(body []byte, expiration string, headers amqp.Table) amqp.Publishing {
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Expiration:   expiration,
+		Headers:      headers,
+		Body:         body,
+	}
+}
+
+// resultHeaders returns the filtering headers of result.
+func resultHeaders(result TranscriptionResult) amqp.Table {
+	headers := amqp.Table{ModelHeader: result.Model}
+	if result.Language != "" {
+		headers[LanguageHeader] = result.Language
+	}
+	return headers
+}
+
+// resultExpiration returns the AMQP expiration of result published at now:
+// the time left until its ExpiresAt, or the producer's result TTL. A result
+// already past ExpiresAt gets "0" and is dead-lettered unless a consumer
+// takes it immediately.
+func (p *Producer) resultExpiration(result TranscriptionResult, now time.Time) string {
+	if !result.ExpiresAt.IsZero() {
+		remaining := result.ExpiresAt.Sub(now).Milliseconds()
+		return strconv.FormatInt(max(remaining, 0), 10)
+	}
+	if p.resultTTLMs > 0 {
+		return strconv.Itoa(p.resultTTLMs)
+	}
+	return ""
+}
+
+// PublishRetry publishes a message to the delay queue for its next attempt.
 func (p *Producer) PublishRetry(request TranscriptionRequest) error {
+	return p.PublishRetryWithContext(context.Background(), request)
+}
+
+// PublishRetryWithContext is like PublishRetry, but also stops waiting for
+// the broker once ctx is done. The trace context of ctx goes with the
+// retry, so the next attempt joins the same trace.
+func (p *Producer) PublishRetryWithContext(ctx context.Context, request TranscriptionRequest) error {
 	// Increment retry count
 	request.RetryCount++
+	attempt := request.RetryCount
+	if attempt > MaxRetries {
+		attempt = MaxRetries
+	}
 
 	body, err := json.Marshal(request)
 	if err != nil {
 		return fmt.Errorf("failed to marshal retry request: %w", err)
 	}
 
-	err = p.channel.Publish(
-		RetryExchange,   // exchange
-		RetryRoutingKey, // routing key
-		false,           // mandatory
-		false,           // immediate
+	headers := amqp.Table{
+		"x-retry-count": int32(request.RetryCount),
+	}
+
+	// With a topic exchange the original routing key must survive the
+	// dead-letter hop back to MainExchange
+	routingKey := RetryRoutingKeyFor(attempt)
+	if p.exchangeType == ExchangeTopic {
+		routingKey = request.RoutingKey
+		if routingKey == "" {
+			routingKey = RequestRoutingKey(request.Language)
+		}
+		headers[RetryAttemptHeader] = int32(attempt)
+	}
+
+	err = p.publishWithTimestamp(
+		ctx,
+		p.retryCh,
+		RetryExchange, // exchange
+		routingKey,    // routing key
 		amqp.Publishing{
 			ContentType:  "application/json",
 			DeliveryMode: amqp.Persistent,
-			Headers: amqp.Table{
-				"x-retry-count": int32(request.RetryCount),
-			},
-			Body: body,
+			Priority:     request.Priority,
+			Expiration:   strconv.Itoa(p.retry.JitteredDelayMs(attempt)),
+			Headers:      headers,
+			Body:         body,
 		},
 	)
 	if err != nil {
@@ -195,32 +776,188 @@ func (p *Producer) PublishRetry(request TranscriptionRequest) error {
 	return nil
 }
 
-// PublishError publishes an error result when max retries exceeded.
-func (p *Producer) PublishError(attachmentID int, importBatchID *int, errorMessage string) error {
-	result := TranscriptionResult{
+// PublishDead archives a job that exhausted its retries in the dead letter
+// queue. code is one of the ErrCode constants.
+func (p *Producer) PublishDead(request TranscriptionRequest, code string, finalError string) error {
+	return p.PublishDeadWithContext(context.Background(), request, code, finalError)
+}
+
+// PublishDeadWithContext is like PublishDead, but also stops waiting for the
+// broker once ctx is done, and records the trace context of ctx.
+func (p *Producer) PublishDeadWithContext(ctx context.Context, request TranscriptionRequest, code string, finalError string) error {
+	body, err := json.Marshal(DeadLetterMessage{
+		Request:   request,
+		Error:     finalError,
+		ErrorCode: code,
+		FailedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	err = p.publishWithTimestamp(
+		ctx,
+		p.errorCh,
+		DeadLetterExchange,   // exchange
+		DeadLetterRoutingKey, // routing key
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// PublishRejected archives in the dead letter queue the raw delivery of a
+// request rejected before decoding, keeping its body, content type and
+// headers, traceparent included. code is one of the ErrCode constants. MaxMessageSize does not
+// apply, since the body is usually rejected for its size.
+func (p *Producer) PublishRejected(msg amqp.Delivery, code string, reason string) error {
+	headers := make(amqp.Table, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[DeadLetterCodeHeader] = code
+	headers[DeadLetterReasonHeader] = reason
+
+	err := p.confirmPublish(
+		context.Background(),
+		p.errorCh,
+		DeadLetterExchange,        // exchange
+		RejectedRequestRoutingKey, // routing key
+		withSourceTimestamp(amqp.Publishing{
+			Headers:      headers,
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.MessageId,
+			Timestamp:    time.Now().UTC(), // read back as DeadJob.Timestamp
+			Body:         msg.Body,
+		}, time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish rejected request: %w", err)
+	}
+
+	return nil
+}
+
+// PublishErrorWithCode publishes an error result for a job that cannot be
+// processed. code is one of the ErrCode constants.
+func (p *Producer) PublishErrorWithCode(attachmentID int, importBatchID *int, code string, errorMessage string) error {
+	return p.PublishResult(p.ErrorResult(attachmentID, importBatchID, code, errorMessage))
+}
+
+// ErrorResult builds the result published by PublishErrorWithCode.
+func (p *Producer) ErrorResult(attachmentID int, importBatchID *int, code string, errorMessage string) TranscriptionResult {
+	return TranscriptionResult{
 		AttachmentID:  attachmentID,
 		Texto:         "",
 		Duration:      0,
-		Model:         p.model,
+		Model:         p.Model(),
 		Success:       false,
 		ImportBatchID: importBatchID,
 		ErrorMessage:  errorMessage,
+		ErrorCode:     code,
 	}
-	return p.PublishResult(result)
 }
 
 // PublishSuccess publishes a successful transcription result.
-func (p *Producer) PublishSuccess(attachmentID int, importBatchID *int, texto string, duration float64, processingTimeMs int64) error {
-	result := TranscriptionResult{
+// model is the model the Python worker reports having used; when empty the
+// producer's model is reported instead.
+// isSilent marks results whose audio was skipped because it contained no sound.
+// When texto is empty it is rebuilt from segments.
+func (p *Producer) PublishSuccess(attachmentID int, importBatchID *int, texto string, duration float64, model string, processingTimeMs int64, isSilent bool, segments []Segment) error {
+	return p.PublishResult(p.SuccessResult(attachmentID, importBatchID, texto, duration, model, processingTimeMs, isSilent, segments))
+}
+
+// SuccessResult builds the result published by PublishSuccess.
+func (p *Producer) SuccessResult(attachmentID int, importBatchID *int, texto string, duration float64, model string, processingTimeMs int64, isSilent bool, segments []Segment) TranscriptionResult {
+	if texto == "" {
+		texto = SegmentsText(segments)
+	}
+	if model == "" {
+		model = p.Model()
+	}
+	return TranscriptionResult{
 		AttachmentID:     attachmentID,
 		Texto:            texto,
 		Duration:         duration,
-		Model:            p.model,
+		Model:            model,
 		Success:          true,
 		ImportBatchID:    importBatchID,
 		ProcessingTimeMs: processingTimeMs,
+		IsSilent:         isSilent,
+		Segments:         segments,
+	}
+}
+
+// publishWithTimestamp publishes msg on ch with SourceTimestampHeader set
+// to now and the trace context of ctx, if any, in the traceparent header,
+// and waits for the broker to confirm it.
+func (p *Producer) publishWithTimestamp(ctx context.Context, ch *producerChannel, exchange, routingKey string, msg amqp.Publishing) error {
+	msg = withSourceTimestamp(msg, time.Now())
+	telemetry.Inject(ctx, msg.Headers) // a copy, see withSourceTimestamp
+	return p.publishWithConfirm(ctx, ch, exchange, routingKey, msg)
+}
+
+// withSourceTimestamp returns msg with SourceTimestampHeader set to at,
+// without modifying the headers of msg.
+func withSourceTimestamp(msg amqp.Publishing, at time.Time) amqp.Publishing {
+	headers := make(amqp.Table, len(msg.Headers)+1)
+	for k, v := range msg.Headers {
+		headers[k] = v
 	}
-	return p.PublishResult(result)
+	headers[SourceTimestampHeader] = at.UnixMilli()
+	msg.Headers = headers
+	return msg
+}
+
+// publishWithConfirm publishes msg on ch and waits for the broker to
+// confirm it. A broker NACK or a confirmation timeout is returned as an
+// error so the caller can requeue the job instead of silently losing the
+// message. Waiting also stops once ctx is done.
+func (p *Producer) publishWithConfirm(ctx context.Context, ch *producerChannel, exchange, routingKey string, msg amqp.Publishing) error {
+	if p.maxMessageSize > 0 && len(msg.Body) > p.maxMessageSize {
+		return fmt.Errorf("%w: %d > %d bytes", ErrMessageTooLarge, len(msg.Body), p.maxMessageSize)
+	}
+	return p.confirmPublish(ctx, ch, exchange, routingKey, msg)
+}
+
+// confirmPublish is publishWithConfirm without the MaxMessageSize check.
+func (p *Producer) confirmPublish(ctx context.Context, ch *producerChannel, exchange, routingKey string, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(ctx, p.confirmTimeout)
+	defer cancel()
+
+	channel, err := ch.ensure(p.broker)
+	if err != nil {
+		return err
+	}
+
+	confirm, err := channel.Publish(
+		ctx,
+		exchange,   // exchange
+		routingKey, // routing key
+		false,      // mandatory
+		false,      // immediate
+		msg,
+	)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("no confirmation after %v: %w", p.confirmTimeout, err)
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected message for %s", exchange)
+	}
+	return nil
 }
 
 // ShouldRetry checks if a request should be retried based on retry count.
@@ -228,10 +965,13 @@ func ShouldRetry(retryCount int) bool {
 	return retryCount < MaxRetries
 }
 
-// Close closes the producer channel.
+// Close closes the producer channels.
 func (p *Producer) Close() error {
-	if p.channel != nil {
-		return p.channel.Close()
+	var firstErr error
+	for _, ch := range p.channels() {
+		if err := ch.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return nil
+	return firstErr
 }
@@ -0,0 +1,92 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// producerChannel is a confirm-mode channel used for one kind of publish.
+// It is re-opened, and its topology re-declared, after the broker closes it.
+type producerChannel struct {
+	name    string              // "result", "retry" or "error", for logs
+	declare func(Channel) error // topology published to
+	mu      sync.Mutex          // guards channel
+	channel Channel
+	reopens atomic.Int64
+}
+
+// newProducerChannel creates a producerChannel that is not open yet.
+func newProducerChannel(name string, declare func(Channel) error) *producerChannel {
+	return &producerChannel{name: name, declare: declare}
+}
+
+// open opens the channel, declares its topology and enables confirm mode.
+func (c *producerChannel) open(broker Broker) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	channel, err := c.dial(broker)
+	if err != nil {
+		return err
+	}
+	c.channel = channel
+	return nil
+}
+
+// dial opens a configured channel. Caller must hold c.mu.
+func (c *producerChannel) dial(broker Broker) (Channel, error) {
+	channel, err := broker.OpenChannel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s channel: %w", c.name, err)
+	}
+
+	// Declare topology
+	if err := c.declare(channel); err != nil {
+		channel.Close()
+		return nil, err
+	}
+
+	// Enable publisher confirms so publishes are acknowledged by the broker
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return nil, fmt.Errorf("failed to enable confirm mode on %s channel: %w", c.name, err)
+	}
+
+	return channel, nil
+}
+
+// ensure returns the channel, re-opening it if the broker closed it, for
+// example after a protocol error, without re-dialing the connection.
+func (c *producerChannel) ensure(broker Broker) (Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.channel != nil && !c.channel.IsClosed() {
+		return c.channel, nil
+	}
+
+	channel, err := c.dial(broker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen producer %s channel: %w", c.name, err)
+	}
+	c.channel = channel
+	c.reopens.Add(1)
+
+	slog.Warn("⚠️  Producer channel reopened",
+		slog.String("channel", c.name),
+		slog.Int64("reopens", c.reopens.Load()))
+	return channel, nil
+}
+
+// close closes the channel if it was opened.
+func (c *producerChannel) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.channel == nil {
+		return nil
+	}
+	return c.channel.Close()
+}
@@ -0,0 +1,406 @@
+package rabbitmq_test
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/rabbitmq/rabbitmqtest"
+	"whisper-local/internal/telemetry"
+)
+
+// testTimeout bounds every wait on the mock broker.
+const testTimeout = 5 * time.Second
+
+// nextJob returns the next job of jobs, failing the test after testTimeout.
+func nextJob(t *testing.T, jobs <-chan rabbitmq.Job) rabbitmq.Job {
+	t.Helper()
+	select {
+	case job, ok := <-jobs:
+		if !ok {
+			t.Fatal("jobs channel closed")
+		}
+		return job
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a job")
+	}
+	return rabbitmq.Job{}
+}
+
+// nextDelivery returns the next message of queue, failing the test after
+// testTimeout.
+func nextDelivery(t *testing.T, deliveries <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+	select {
+	case msg := <-deliveries:
+		return msg
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a message")
+	}
+	return amqp.Delivery{}
+}
+
+// publishRequest publishes request to MainExchange as an upstream service would.
+func publishRequest(t *testing.T, broker *rabbitmqtest.MockBroker, request rabbitmq.TranscriptionRequest) {
+	t.Helper()
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = broker.Publish(rabbitmq.MainExchange, rabbitmq.MainRoutingKey, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// deathQueues returns the queues recorded in the x-death header of msg.
+func deathQueues(msg amqp.Delivery) map[string]bool {
+	queues := make(map[string]bool)
+	deaths, _ := msg.Headers["x-death"].([]interface{})
+	for _, d := range deaths {
+		death, _ := d.(amqp.Table)
+		if queue, ok := death["queue"].(string); ok {
+			queues[queue] = true
+		}
+	}
+	return queues
+}
+
+func TestPublishRetry_RoutesToAttemptQueue(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	policy := rabbitmq.RetryPolicy{BaseDelayMs: 60000, MaxDelayMs: 600000, JitterPct: 0.2}
+	producer, err := rabbitmq.NewProducerFromBroker(broker, rabbitmq.ProducerOptions{Model: "base", Retry: policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	for attempt := 1; attempt <= rabbitmq.MaxRetries; attempt++ {
+		key := rabbitmq.RetryExchange + ":" + rabbitmq.RetryRoutingKeyFor(attempt)
+		if got := broker.Bindings()[key]; got != rabbitmq.RetryQueueName(attempt) {
+			t.Errorf("binding %s = %q, want %q", key, got, rabbitmq.RetryQueueName(attempt))
+		}
+
+		request := rabbitmq.TranscriptionRequest{AttachmentID: 7, AudioFilePath: "/audio/a.mp3", RetryCount: attempt - 1}
+		if err := producer.PublishRetry(request); err != nil {
+			t.Fatalf("PublishRetry attempt %d: %v", attempt, err)
+		}
+
+		msg := nextDelivery(t, broker.Consume(rabbitmq.RetryQueueName(attempt)))
+		if got := msg.Headers["x-retry-count"]; got != int32(attempt) {
+			t.Errorf("attempt %d: x-retry-count = %v, want %d", attempt, got, attempt)
+		}
+		expiration, err := strconv.Atoi(msg.Expiration)
+		if err != nil {
+			t.Fatalf("attempt %d: expiration %q: %v", attempt, msg.Expiration, err)
+		}
+		delay := float64(policy.DelayMs(attempt))
+		if min, max := delay*(1-policy.JitterPct), delay*(1+policy.JitterPct); float64(expiration) < min || float64(expiration) > max {
+			t.Errorf("attempt %d: expiration %d ms outside [%v, %v]", attempt, expiration, min, max)
+		}
+	}
+}
+
+func TestRetryFlow_DeadLettersAfterMaxRetries(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	producer := rabbitmqtest.NewMockProducer(broker)
+	defer producer.Close()
+	consumer := rabbitmqtest.NewMockConsumer(broker)
+	defer consumer.Close()
+
+	jobs, err := consumer.Consume()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadLetters := broker.Consume(rabbitmq.DeadLetterQueue)
+
+	publishRequest(t, broker, rabbitmq.TranscriptionRequest{AttachmentID: 42, AudioFilePath: "/audio/a.mp3"})
+
+	// Fail every attempt the way the worker pool does: retry while
+	// ShouldRetry allows it, then archive the job
+	for attempt := 0; attempt <= rabbitmq.MaxRetries; attempt++ {
+		job := nextJob(t, jobs)
+		if job.Request.AttachmentID != 42 {
+			t.Fatalf("attempt %d: got attachment %d", attempt, job.Request.AttachmentID)
+		}
+		if job.Request.RetryCount != attempt {
+			t.Fatalf("attempt %d: RetryCount = %d", attempt, job.Request.RetryCount)
+		}
+		// Each retry is a new message, so x-death only holds its own hop
+		if attempt > 0 && !deathQueues(job.Delivery)[rabbitmq.RetryQueueName(attempt)] {
+			t.Errorf("attempt %d: x-death does not record %s", attempt, rabbitmq.RetryQueueName(attempt))
+		}
+
+		if rabbitmq.ShouldRetry(job.Request.RetryCount) {
+			err = producer.PublishRetry(job.Request)
+		} else {
+			err = producer.PublishDead(job.Request, rabbitmq.ErrCodeMaxRetries, "python error")
+		}
+		if err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if err := job.Delivery.Ack(false); err != nil {
+			t.Fatalf("attempt %d: ack: %v", attempt, err)
+		}
+	}
+
+	msg := nextDelivery(t, deadLetters)
+	if msg.RoutingKey != rabbitmq.DeadLetterRoutingKey {
+		t.Errorf("dead letter routing key = %q, want %q", msg.RoutingKey, rabbitmq.DeadLetterRoutingKey)
+	}
+	var dead rabbitmq.DeadLetterMessage
+	if err := json.Unmarshal(msg.Body, &dead); err != nil {
+		t.Fatal(err)
+	}
+	if dead.Request.AttachmentID != 42 || dead.Request.RetryCount != rabbitmq.MaxRetries {
+		t.Errorf("dead letter request = %+v", dead.Request)
+	}
+	if dead.ErrorCode != rabbitmq.ErrCodeMaxRetries || dead.Error != "python error" {
+		t.Errorf("dead letter error = %q (%s)", dead.Error, dead.ErrorCode)
+	}
+	if n := broker.QueueLen(rabbitmq.MainQueue); n != 0 {
+		t.Errorf("%d messages left in %s", n, rabbitmq.MainQueue)
+	}
+}
+
+func TestPublish_NackedConfirmIsAnError(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	producer := rabbitmqtest.NewMockProducer(broker)
+	defer producer.Close()
+	request := rabbitmq.TranscriptionRequest{AttachmentID: 3, AudioFilePath: "/audio/a.mp3"}
+
+	tests := []struct {
+		name     string
+		exchange string
+		publish  func() error
+	}{
+		{"result", rabbitmq.ResultsExchange, func() error {
+			return producer.PublishSuccess(3, nil, "hola", 1.5, "", 10, false, nil)
+		}},
+		{"retry", rabbitmq.RetryExchange, func() error {
+			return producer.PublishRetry(request)
+		}},
+		{"dead letter", rabbitmq.DeadLetterExchange, func() error {
+			return producer.PublishDead(request, rabbitmq.ErrCodeMaxRetries, "python error")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker.RejectPublishes(tt.exchange, true)
+			if err := tt.publish(); err == nil {
+				t.Fatal("publish succeeded although the broker nacked it")
+			}
+
+			broker.RejectPublishes(tt.exchange, false)
+			if err := tt.publish(); err != nil {
+				t.Fatalf("publish after the broker acks again: %v", err)
+			}
+		})
+	}
+}
+
+func TestPublishRetry_TopicKeepsRoutingKey(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	producer, err := rabbitmq.NewProducerFromBroker(broker, rabbitmq.ProducerOptions{
+		Model:        "base",
+		Retry:        rabbitmq.RetryPolicy{BaseDelayMs: 3600000, MaxDelayMs: 3600000},
+		ExchangeType: rabbitmq.ExchangeTopic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	// One reader per queue: a reader left behind would take the next message
+	retries := make(map[int]<-chan amqp.Delivery)
+	for attempt := 1; attempt <= rabbitmq.MaxRetries; attempt++ {
+		retries[attempt] = broker.Consume(rabbitmq.RetryQueueName(attempt))
+	}
+
+	tests := []struct {
+		name    string
+		request rabbitmq.TranscriptionRequest
+		wantKey string
+	}{
+		{"original routing key", rabbitmq.TranscriptionRequest{AttachmentID: 1, RoutingKey: "es.transcription.request"}, "es.transcription.request"},
+		{"from language", rabbitmq.TranscriptionRequest{AttachmentID: 2, Language: "en"}, "en.transcription.request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := tt.request
+			for attempt := 1; attempt <= rabbitmq.MaxRetries; attempt++ {
+				request.RetryCount = attempt - 1
+				if err := producer.PublishRetry(request); err != nil {
+					t.Fatalf("PublishRetry attempt %d: %v", attempt, err)
+				}
+
+				// The headers exchange picks the queue from x-retry-attempt
+				msg := nextDelivery(t, retries[attempt])
+				if msg.RoutingKey != tt.wantKey {
+					t.Errorf("attempt %d: routing key = %q, want %q", attempt, msg.RoutingKey, tt.wantKey)
+				}
+				if got := msg.Headers[rabbitmq.RetryAttemptHeader]; got != int32(attempt) {
+					t.Errorf("attempt %d: %s = %v", attempt, rabbitmq.RetryAttemptHeader, got)
+				}
+				if got := msg.Headers["x-retry-count"]; got != int32(attempt) {
+					t.Errorf("attempt %d: x-retry-count = %v", attempt, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTopicMode_RetryReturnsToLanguageQueue(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	producer, err := rabbitmq.NewProducerFromBroker(broker, rabbitmq.ProducerOptions{
+		Model:        "base",
+		Retry:        rabbitmq.RetryPolicy{BaseDelayMs: 1, MaxDelayMs: 1},
+		ExchangeType: rabbitmq.ExchangeTopic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	consumers := make(map[string]<-chan rabbitmq.Job)
+	for _, language := range []string{"es", "en"} {
+		queue := "whisper_" + language
+		consumer, err := rabbitmq.NewConsumerFromBroker(broker, rabbitmq.PrefetchConfig{GlobalPrefetch: 10}, rabbitmq.Topology{
+			ExchangeType: rabbitmq.ExchangeTopic,
+			Queue:        queue,
+			BindingKey:   language + ".#",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer consumer.Close()
+		if got := broker.Bindings()[rabbitmq.MainExchange+":"+language+".#"]; got != queue {
+			t.Errorf("binding %s.# = %q, want %q", language, got, queue)
+		}
+		if consumers[language], err = consumer.Consume(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, language := range []string{"es", "en"} {
+		body, err := json.Marshal(rabbitmq.TranscriptionRequest{AttachmentID: 10 + i, AudioFilePath: "/audio/a.mp3", Language: language})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := broker.Publish(rabbitmq.MainExchange, rabbitmq.RequestRoutingKey(language), amqp.Publishing{Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each request reaches only the queue bound to its language
+	if job := nextJob(t, consumers["en"]); job.Request.AttachmentID != 11 {
+		t.Errorf("en consumer got attachment %d", job.Request.AttachmentID)
+	}
+	job := nextJob(t, consumers["es"])
+	if job.Request.AttachmentID != 10 || job.Request.RoutingKey != "es.transcription.request" {
+		t.Fatalf("es consumer got %+v", job.Request)
+	}
+
+	// Every retry dead-letters back to the es queue with its routing key
+	for attempt := 1; attempt <= rabbitmq.MaxRetries; attempt++ {
+		if err := producer.PublishRetry(job.Request); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if err := job.Delivery.Ack(false); err != nil {
+			t.Fatal(err)
+		}
+
+		job = nextJob(t, consumers["es"])
+		if job.Request.AttachmentID != 10 || job.Request.RetryCount != attempt {
+			t.Fatalf("attempt %d: got %+v", attempt, job.Request)
+		}
+		if job.Delivery.RoutingKey != "es.transcription.request" {
+			t.Errorf("attempt %d: routing key = %q", attempt, job.Delivery.RoutingKey)
+		}
+		if !deathQueues(job.Delivery)[rabbitmq.RetryQueueName(attempt)] {
+			t.Errorf("attempt %d: x-death does not record %s", attempt, rabbitmq.RetryQueueName(attempt))
+		}
+	}
+	job.Delivery.Ack(false)
+
+	select {
+	case job := <-consumers["en"]:
+		t.Errorf("retry reached the en queue: %+v", job.Request)
+	default:
+	}
+}
+
+func TestPublish_PropagatesTraceparent(t *testing.T) {
+	broker := rabbitmqtest.NewMockBroker()
+	producer, err := rabbitmq.NewProducerFromBroker(broker, rabbitmq.ProducerOptions{
+		Model: "base",
+		Retry: rabbitmq.RetryPolicy{BaseDelayMs: 3600000, MaxDelayMs: 3600000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := telemetry.ParseTraceparent(traceparent)
+	if !ok {
+		t.Fatal("invalid test traceparent")
+	}
+	ctx := telemetry.ContextWithSpanContext(context.Background(), sc)
+	request := rabbitmq.TranscriptionRequest{AttachmentID: 9, AudioFilePath: "/audio/a.mp3"}
+	queues := map[string]<-chan amqp.Delivery{
+		rabbitmq.ResultsQueue:      broker.Consume(rabbitmq.ResultsQueue),
+		rabbitmq.RetryQueueName(1): broker.Consume(rabbitmq.RetryQueueName(1)),
+		rabbitmq.DeadLetterQueue:   broker.Consume(rabbitmq.DeadLetterQueue),
+	}
+
+	tests := []struct {
+		name    string
+		queue   string
+		publish func() error
+	}{
+		{"result", rabbitmq.ResultsQueue, func() error {
+			return producer.PublishResultWithContext(ctx, producer.SuccessResult(9, nil, "hola", 1.5, "", 10, false, nil))
+		}},
+		{"retry", rabbitmq.RetryQueueName(1), func() error {
+			return producer.PublishRetryWithContext(ctx, request)
+		}},
+		{"dead letter", rabbitmq.DeadLetterQueue, func() error {
+			return producer.PublishDeadWithContext(ctx, request, rabbitmq.ErrCodeMaxRetries, "python error")
+		}},
+		{"rejected request", rabbitmq.DeadLetterQueue, func() error {
+			return producer.PublishRejected(amqp.Delivery{
+				Headers: amqp.Table{telemetry.TraceparentHeader: traceparent},
+				Body:    []byte("{"),
+			}, rabbitmq.ErrCodeMessageTooLarge, "too large")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.publish(); err != nil {
+				t.Fatal(err)
+			}
+			msg := nextDelivery(t, queues[tt.queue])
+			if got := msg.Headers[telemetry.TraceparentHeader]; got != traceparent {
+				t.Errorf("traceparent = %v, want %s", got, traceparent)
+			}
+		})
+	}
+
+	// Without a trace context no header is added
+	if err := producer.PublishRetry(request); err != nil {
+		t.Fatal(err)
+	}
+	msg := nextDelivery(t, queues[rabbitmq.RetryQueueName(1)])
+	if got, ok := msg.Headers[telemetry.TraceparentHeader]; ok {
+		t.Errorf("traceparent = %v without a trace context", got)
+	}
+}
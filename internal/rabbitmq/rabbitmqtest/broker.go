@@ -0,0 +1,741 @@
+// Package rabbitmqtest provides test doubles for the rabbitmq package.
+package rabbitmqtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"whisper-local/internal/rabbitmq"
+)
+
+// MockBroker is an in-memory rabbitmq.Broker with the queue semantics the
+// orchestrator relies on: direct, fanout, topic and headers exchanges,
+// the default exchange, message priorities, per-message and per-queue TTLs,
+// dead-lettering with x-death headers, redelivery of requeued and unacked
+// messages, and publisher confirms. Prefetch limits are not enforced.
+type MockBroker struct {
+	mu        sync.Mutex
+	exchanges map[string]string // name to type
+	queues    map[string]*mockQueue
+	bindings  []mockBinding
+	rejected  map[string]bool // exchanges whose publishes are nacked
+	channels  map[*mockChannel]struct{}
+}
+
+// mockQueue is a queue of MockBroker. Its fields are guarded by the broker.
+type mockQueue struct {
+	name  string
+	args  amqp.Table
+	ready []*mockMessage
+	cond  *sync.Cond // signalled when ready grows or a consumer stops
+}
+
+// mockMessage is a message waiting in a queue.
+type mockMessage struct {
+	delivery amqp.Delivery
+	expiry   *time.Timer // nil without a TTL
+}
+
+// mockBinding routes messages from exchange to queue.
+type mockBinding struct {
+	queue, key, exchange string
+	args                 amqp.Table
+}
+
+var _ rabbitmq.Broker = (*MockBroker)(nil)
+
+// NewMockBroker returns an empty broker.
+func NewMockBroker() *MockBroker {
+	return &MockBroker{
+		exchanges: make(map[string]string),
+		queues:    make(map[string]*mockQueue),
+		rejected:  make(map[string]bool),
+		channels:  make(map[*mockChannel]struct{}),
+	}
+}
+
+// NewMockConsumer returns a Consumer of rabbitmq.MainQueue with the default
+// topology and a prefetch of 10, declared on broker.
+func NewMockConsumer(broker *MockBroker) *rabbitmq.Consumer {
+	consumer, err := rabbitmq.NewConsumerFromBroker(broker, rabbitmq.PrefetchConfig{GlobalPrefetch: 10}, rabbitmq.Topology{})
+	if err != nil {
+		panic("rabbitmqtest: declaring the consumer topology failed: " + err.Error())
+	}
+	return consumer
+}
+
+// NewMockProducer returns a Producer with default options whose topology is
+// declared on broker. Retries are delayed by one millisecond so retry flows
+// complete quickly.
+func NewMockProducer(broker *MockBroker) *rabbitmq.Producer {
+	producer, err := rabbitmq.NewProducerFromBroker(broker, rabbitmq.ProducerOptions{
+		Model: "base",
+		Retry: rabbitmq.RetryPolicy{BaseDelayMs: 1, MaxDelayMs: 1},
+	})
+	if err != nil {
+		panic("rabbitmqtest: declaring the producer topology failed: " + err.Error())
+	}
+	return producer
+}
+
+// OpenChannel opens a channel on the broker.
+func (b *MockBroker) OpenChannel() (rabbitmq.Channel, error) {
+	ch := &mockChannel{broker: b, unacked: make(map[uint64]*mockUnacked)}
+	b.mu.Lock()
+	b.channels[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, nil
+}
+
+// Publish routes msg from exchange with routingKey, as a publish on a
+// channel would. The empty exchange is the default exchange, which routes
+// to the queue named routingKey. Unroutable messages are dropped.
+func (b *MockBroker) Publish(exchange, routingKey string, msg amqp.Publishing) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.publishLocked(exchange, routingKey, msg)
+}
+
+// Consume returns the messages of queue as they arrive, removing them from
+// the queue as if consumed with auto-ack. The channel is never closed.
+func (b *MockBroker) Consume(queue string) <-chan amqp.Delivery {
+	b.mu.Lock()
+	q := b.queueLocked(queue)
+	b.mu.Unlock()
+
+	out := make(chan amqp.Delivery)
+	go func() {
+		for {
+			b.mu.Lock()
+			for len(q.ready) == 0 {
+				q.cond.Wait()
+			}
+			msg := q.pop()
+			b.mu.Unlock()
+			out <- msg.delivery
+		}
+	}()
+	return out
+}
+
+// Bindings returns the bindings declared so far, keyed by
+// "exchange:routing_key", with the bound queue as value. Queues sharing
+// a key are listed comma separated, sorted.
+func (b *MockBroker) Bindings() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	queues := make(map[string][]string)
+	for _, binding := range b.bindings {
+		key := binding.exchange + ":" + binding.key
+		queues[key] = append(queues[key], binding.queue)
+	}
+	bindings := make(map[string]string, len(queues))
+	for key, names := range queues {
+		sort.Strings(names)
+		bindings[key] = strings.Join(names, ",")
+	}
+	return bindings
+}
+
+// QueueLen returns the messages ready in queue, not counting those
+// delivered and not yet acknowledged.
+func (b *MockBroker) QueueLen(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queue]; ok {
+		return len(q.ready)
+	}
+	return 0
+}
+
+// RejectPublishes makes the broker nack the publisher confirms of every
+// message published to exchange, or ack them again if reject is false.
+func (b *MockBroker) RejectPublishes(exchange string, reject bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rejected[exchange] = reject
+}
+
+// CloseChannels closes every open channel, as the broker does on a
+// connection loss: consumers see their deliveries stop and unacked
+// messages are requeued as redelivered.
+func (b *MockBroker) CloseChannels() {
+	b.mu.Lock()
+	channels := make([]*mockChannel, 0, len(b.channels))
+	for ch := range b.channels {
+		channels = append(channels, ch)
+	}
+	b.mu.Unlock()
+
+	for _, ch := range channels {
+		ch.close(amqp.ErrClosed)
+	}
+}
+
+// queueLocked returns queue, declaring it without arguments if needed.
+// Caller must hold b.mu.
+func (b *MockBroker) queueLocked(name string) *mockQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &mockQueue{name: name, cond: sync.NewCond(&b.mu)}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// publishLocked routes msg to every matching queue. Caller must hold b.mu.
+func (b *MockBroker) publishLocked(exchange, routingKey string, msg amqp.Publishing) error {
+	if exchange == "" {
+		if q, ok := b.queues[routingKey]; ok {
+			b.enqueueLocked(q, exchange, routingKey, msg)
+		}
+		return nil
+	}
+
+	kind, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("no exchange %q", exchange)
+	}
+	for _, binding := range b.bindings {
+		if binding.exchange == exchange && routes(kind, binding, routingKey, msg.Headers) {
+			b.enqueueLocked(b.queues[binding.queue], exchange, routingKey, msg)
+		}
+	}
+	return nil
+}
+
+// routes reports whether a message sent with routingKey and headers to an
+// exchange of kind matches binding.
+func routes(kind string, binding mockBinding, routingKey string, headers amqp.Table) bool {
+	switch kind {
+	case "fanout":
+		return true
+	case "topic":
+		return topicMatch(strings.Split(binding.key, "."), strings.Split(routingKey, "."))
+	case "headers":
+		return headersMatch(binding.args, headers)
+	default:
+		return binding.key == routingKey
+	}
+}
+
+// topicMatch matches the words of a routing key against a topic pattern,
+// where * is exactly one word and # is zero or more.
+func topicMatch(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if topicMatch(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && topicMatch(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && topicMatch(pattern[1:], words[1:])
+	}
+}
+
+// headersMatch applies the x-match rule of binding arguments to headers.
+// Like RabbitMQ, x- arguments are only compared with any-with-x and
+// all-with-x.
+func headersMatch(args, headers amqp.Table) bool {
+	rule, _ := args["x-match"].(string)
+	matchAny := strings.HasPrefix(rule, "any")
+	withX := strings.HasSuffix(rule, "-with-x")
+	for key, want := range args {
+		if key == "x-match" || (!withX && strings.HasPrefix(key, "x-")) {
+			continue
+		}
+		got, ok := headers[key]
+		equal := ok && sameValue(want, got)
+		if matchAny && equal {
+			return true
+		}
+		if !matchAny && !equal {
+			return false
+		}
+	}
+	return !matchAny
+}
+
+// sameValue compares header values, treating every integer type alike.
+func sameValue(a, b interface{}) bool {
+	x, aInt := toInt64(a)
+	y, bInt := toInt64(b)
+	if aInt || bInt {
+		return aInt && bInt && x == y
+	}
+	return a == b
+}
+
+// toInt64 converts an AMQP integer header value.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// enqueueLocked adds msg to q behind messages of equal or higher priority
+// and starts its TTL. Caller must hold b.mu.
+func (b *MockBroker) enqueueLocked(q *mockQueue, exchange, routingKey string, msg amqp.Publishing) {
+	delivery := amqp.Delivery{
+		Headers:         copyTable(msg.Headers),
+		ContentType:     msg.ContentType,
+		ContentEncoding: msg.ContentEncoding,
+		DeliveryMode:    msg.DeliveryMode,
+		Priority:        msg.Priority,
+		CorrelationId:   msg.CorrelationId,
+		ReplyTo:         msg.ReplyTo,
+		Expiration:      msg.Expiration,
+		MessageId:       msg.MessageId,
+		Timestamp:       msg.Timestamp,
+		Type:            msg.Type,
+		UserId:          msg.UserId,
+		AppId:           msg.AppId,
+		Body:            msg.Body,
+		Exchange:        exchange,
+		RoutingKey:      routingKey,
+	}
+	m := &mockMessage{delivery: delivery}
+	if ttl, ok := messageTTL(q.args, msg.Expiration); ok {
+		m.expiry = time.AfterFunc(ttl, func() { b.expire(q, m) })
+	}
+	q.push(m)
+}
+
+// messageTTL returns the lower of the queue x-message-ttl and the
+// per-message expiration, both in milliseconds.
+func messageTTL(args amqp.Table, expiration string) (time.Duration, bool) {
+	ttl, ok := toInt64(args["x-message-ttl"])
+	if ms, err := strconv.ParseInt(expiration, 10, 64); err == nil && (!ok || ms < ttl) {
+		ttl, ok = ms, true
+	}
+	return time.Duration(ttl) * time.Millisecond, ok
+}
+
+// expire dead-letters m if it is still waiting in q.
+func (b *MockBroker) expire(q *mockQueue, m *mockMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, ready := range q.ready {
+		if ready == m {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			b.deadLetterLocked(q, m.delivery, "expired")
+			return
+		}
+	}
+}
+
+// deadLetterLocked republishes a message removed from q to its dead letter
+// exchange, recording the death in x-death, or drops it if q has none.
+// Caller must hold b.mu.
+func (b *MockBroker) deadLetterLocked(q *mockQueue, d amqp.Delivery, reason string) {
+	exchange, ok := q.args["x-dead-letter-exchange"].(string)
+	if !ok {
+		return
+	}
+	routingKey := d.RoutingKey
+	if key, ok := q.args["x-dead-letter-routing-key"].(string); ok {
+		routingKey = key
+	}
+
+	headers := copyTable(d.Headers)
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+	headers["x-death"] = addDeath(headers["x-death"], q.name, reason, d.Exchange, d.RoutingKey)
+
+	b.publishLocked(exchange, routingKey, amqp.Publishing{
+		Headers:         headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    d.DeliveryMode,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		UserId:          d.UserId,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	})
+}
+
+// addDeath returns the x-death header with a death in queue for reason
+// counted: the matching entry's count is incremented and moved first, or
+// a new entry is added first.
+func addDeath(existing interface{}, queue, reason, exchange, routingKey string) []interface{} {
+	deaths, _ := existing.([]interface{})
+	for i, d := range deaths {
+		death, _ := d.(amqp.Table)
+		if death["queue"] == queue && death["reason"] == reason {
+			count, _ := toInt64(death["count"])
+			updated := copyTable(death)
+			updated["count"] = count + 1
+			rest := append(append([]interface{}{}, deaths[:i]...), deaths[i+1:]...)
+			return append([]interface{}{updated}, rest...)
+		}
+	}
+	death := amqp.Table{
+		"count":        int64(1),
+		"queue":        queue,
+		"reason":       reason,
+		"exchange":     exchange,
+		"routing-keys": []interface{}{routingKey},
+		"time":         time.Now(),
+	}
+	return append([]interface{}{death}, deaths...)
+}
+
+// copyTable returns a shallow copy of t, nil if t is nil.
+func copyTable(t amqp.Table) amqp.Table {
+	if t == nil {
+		return nil
+	}
+	c := make(amqp.Table, len(t))
+	for k, v := range t {
+		c[k] = v
+	}
+	return c
+}
+
+// push adds m behind the messages of equal or higher priority. Caller must
+// hold the broker lock.
+func (q *mockQueue) push(m *mockMessage) {
+	i := len(q.ready)
+	if _, priority := q.args["x-max-priority"]; priority {
+		for i > 0 && q.ready[i-1].delivery.Priority < m.delivery.Priority {
+			i--
+		}
+	}
+	q.ready = append(q.ready, nil)
+	copy(q.ready[i+1:], q.ready[i:])
+	q.ready[i] = m
+	q.cond.Broadcast()
+}
+
+// pop removes the first ready message and stops its TTL. Caller must hold
+// the broker lock and ensure a message is ready.
+func (q *mockQueue) pop() *mockMessage {
+	m := q.ready[0]
+	q.ready = q.ready[1:]
+	if m.expiry != nil {
+		m.expiry.Stop()
+	}
+	return m
+}
+
+// mockChannel is a channel of MockBroker.
+type mockChannel struct {
+	broker *MockBroker
+
+	// Guarded by broker.mu
+	closed     bool
+	confirm    bool
+	nextTag    uint64
+	unacked    map[uint64]*mockUnacked
+	onClose    []chan *amqp.Error
+	onCancel   []chan string
+	stopSignal chan struct{} // closed with the channel
+}
+
+// mockUnacked is a message delivered on a channel and not yet acknowledged.
+type mockUnacked struct {
+	queue    *mockQueue
+	delivery amqp.Delivery
+}
+
+var _ rabbitmq.Channel = (*mockChannel)(nil)
+
+// errChannelClosed is returned by operations on a closed channel.
+var errChannelClosed = errors.New("rabbitmqtest: channel closed")
+
+// ExchangeDeclare declares an exchange; redeclaring it is a no-op.
+func (c *mockChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return errChannelClosed
+	}
+	if _, ok := c.broker.exchanges[name]; !ok {
+		c.broker.exchanges[name] = kind
+	}
+	return nil
+}
+
+// QueueDeclare declares a queue; redeclaring it keeps its arguments.
+func (c *mockChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return amqp.Queue{}, errChannelClosed
+	}
+	_, existed := c.broker.queues[name]
+	q := c.broker.queueLocked(name)
+	if !existed {
+		q.args = copyTable(args)
+	}
+	return amqp.Queue{Name: name, Messages: len(q.ready)}, nil
+}
+
+// QueueBind binds a declared queue to a declared exchange.
+func (c *mockChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return errChannelClosed
+	}
+	if _, ok := c.broker.queues[name]; !ok {
+		return fmt.Errorf("no queue %q", name)
+	}
+	if _, ok := c.broker.exchanges[exchange]; !ok {
+		return fmt.Errorf("no exchange %q", exchange)
+	}
+	for _, binding := range c.broker.bindings {
+		if binding.queue == name && binding.key == key && binding.exchange == exchange {
+			return nil
+		}
+	}
+	c.broker.bindings = append(c.broker.bindings, mockBinding{queue: name, key: key, exchange: exchange, args: copyTable(args)})
+	return nil
+}
+
+// Qos is accepted and ignored; MockBroker does not enforce prefetch.
+func (c *mockChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	return nil
+}
+
+// Confirm puts the channel in confirm mode.
+func (c *mockChannel) Confirm(noWait bool) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	c.confirm = true
+	return nil
+}
+
+// Consume delivers the messages of queue on the returned channel until the
+// channel is closed. Unless autoAck is set, each delivery must be
+// acknowledged; requeued messages are delivered again as redelivered.
+func (c *mockChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return nil, errChannelClosed
+	}
+	q, ok := c.broker.queues[queue]
+	if !ok {
+		return nil, fmt.Errorf("no queue %q", queue)
+	}
+	if c.stopSignal == nil {
+		c.stopSignal = make(chan struct{})
+	}
+
+	out := make(chan amqp.Delivery)
+	go c.deliver(q, consumer, autoAck, out, c.stopSignal)
+	return out, nil
+}
+
+// deliver hands the messages of q to out until stop is closed, then closes out.
+func (c *mockChannel) deliver(q *mockQueue, consumer string, autoAck bool, out chan amqp.Delivery, stop chan struct{}) {
+	defer close(out)
+	b := c.broker
+	for {
+		b.mu.Lock()
+		for len(q.ready) == 0 && !c.closed {
+			q.cond.Wait()
+		}
+		if c.closed {
+			b.mu.Unlock()
+			return
+		}
+		m := q.pop()
+		c.nextTag++
+		d := m.delivery
+		d.DeliveryTag = c.nextTag
+		d.ConsumerTag = consumer
+		d.Acknowledger = c
+		if !autoAck {
+			c.unacked[d.DeliveryTag] = &mockUnacked{queue: q, delivery: d}
+		}
+		b.mu.Unlock()
+
+		select {
+		case out <- d:
+		case <-stop:
+			// The channel closed before the consumer took it
+			b.mu.Lock()
+			if _, ok := c.unacked[d.DeliveryTag]; ok {
+				delete(c.unacked, d.DeliveryTag)
+				d.Redelivered = true
+				q.push(&mockMessage{delivery: d})
+			}
+			b.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Publish routes msg and returns a confirmation that is already settled:
+// acked, unless RejectPublishes was called for exchange.
+func (c *mockChannel) Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (rabbitmq.Confirmation, error) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return nil, errChannelClosed
+	}
+	if err := c.broker.publishLocked(exchange, key, msg); err != nil {
+		return nil, err
+	}
+	return mockConfirmation(!c.broker.rejected[exchange]), nil
+}
+
+// NotifyClose registers ch to receive the error that closes the channel.
+// It is closed afterwards, as amqp091 does.
+func (c *mockChannel) NotifyClose(ch chan *amqp.Error) chan *amqp.Error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	c.onClose = append(c.onClose, ch)
+	return ch
+}
+
+// NotifyCancel registers ch; MockBroker never cancels consumers, so it is
+// only closed with the channel.
+func (c *mockChannel) NotifyCancel(ch chan string) chan string {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	c.onCancel = append(c.onCancel, ch)
+	return ch
+}
+
+// IsClosed reports whether the channel was closed.
+func (c *mockChannel) IsClosed() bool {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	return c.closed
+}
+
+// Close closes the channel gracefully.
+func (c *mockChannel) Close() error {
+	c.close(nil)
+	return nil
+}
+
+// close closes the channel, requeuing its unacked messages as redelivered
+// and reporting amqpErr to NotifyClose listeners if it is not nil.
+func (c *mockChannel) close(amqpErr *amqp.Error) {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	delete(b.channels, c)
+
+	if c.stopSignal != nil {
+		close(c.stopSignal)
+	}
+	for tag, u := range c.unacked {
+		delete(c.unacked, tag)
+		u.delivery.Redelivered = true
+		u.queue.push(&mockMessage{delivery: u.delivery})
+	}
+	for _, q := range b.queues {
+		q.cond.Broadcast() // wake the consumers of this channel
+	}
+	for _, ch := range c.onClose {
+		if amqpErr != nil {
+			ch <- amqpErr
+		}
+		close(ch)
+	}
+	for _, ch := range c.onCancel {
+		close(ch)
+	}
+}
+
+// Ack implements amqp.Acknowledger.
+func (c *mockChannel) Ack(tag uint64, multiple bool) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return errChannelClosed
+	}
+	c.settleLocked(tag, multiple)
+	return nil
+}
+
+// Nack implements amqp.Acknowledger.
+func (c *mockChannel) Nack(tag uint64, multiple, requeue bool) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return errChannelClosed
+	}
+	for _, u := range c.settleLocked(tag, multiple) {
+		if requeue {
+			u.delivery.Redelivered = true
+			u.queue.push(&mockMessage{delivery: u.delivery})
+		} else {
+			c.broker.deadLetterLocked(u.queue, u.delivery, "rejected")
+		}
+	}
+	return nil
+}
+
+// Reject implements amqp.Acknowledger.
+func (c *mockChannel) Reject(tag uint64, requeue bool) error {
+	return c.Nack(tag, false, requeue)
+}
+
+// settleLocked removes and returns the unacked messages settled by tag, in
+// delivery order. Caller must hold broker.mu.
+func (c *mockChannel) settleLocked(tag uint64, multiple bool) []*mockUnacked {
+	var tags []uint64
+	for t := range c.unacked {
+		if t == tag || (multiple && t < tag) {
+			tags = append(tags, t)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	settled := make([]*mockUnacked, 0, len(tags))
+	for _, t := range tags {
+		settled = append(settled, c.unacked[t])
+		delete(c.unacked, t)
+	}
+	return settled
+}
+
+// mockConfirmation is a publisher confirm settled when it is created.
+type mockConfirmation bool
+
+// WaitContext returns whether the broker acked the publish.
+func (c mockConfirmation) WaitContext(ctx context.Context) (bool, error) {
+	return bool(c), nil
+}
@@ -0,0 +1,52 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// RetryPolicy controls the delay applied before each retry attempt.
+// Attempt n waits BaseDelayMs * 2^(n-1), capped at MaxDelayMs, ± JitterPct.
+type RetryPolicy struct {
+	BaseDelayMs int
+	MaxDelayMs  int
+	JitterPct   float64
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		BaseDelayMs: 5000,
+		MaxDelayMs:  60000,
+		JitterPct:   0.2,
+	}
+}
+
+// RetryQueueName returns the delay queue for a retry attempt (1-based).
+func RetryQueueName(attempt int) string {
+	return fmt.Sprintf("%s%d", RetryQueuePrefix, attempt)
+}
+
+// RetryRoutingKeyFor returns the routing key bound to a retry attempt's queue.
+func RetryRoutingKeyFor(attempt int) string {
+	return fmt.Sprintf("%s.%d", RetryRoutingKey, attempt)
+}
+
+// DelayMs returns the base delay for a retry attempt, without jitter.
+func (r RetryPolicy) DelayMs(attempt int) int {
+	delay := r.BaseDelayMs
+	for i := 1; i < attempt && delay < r.MaxDelayMs; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelayMs {
+		delay = r.MaxDelayMs
+	}
+	return delay
+}
+
+// JitteredDelayMs returns the delay for a retry attempt with random jitter applied.
+func (r RetryPolicy) JitteredDelayMs(attempt int) int {
+	delay := float64(r.DelayMs(attempt))
+	jitter := delay * r.JitterPct * (2*rand.Float64() - 1)
+	return int(delay + jitter)
+}
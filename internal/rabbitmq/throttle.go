@@ -0,0 +1,81 @@
+package rabbitmq
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// throttleState tracks whether the rate limit is holding jobs back and
+// notifies a hook on every change.
+type throttleState struct {
+	mu      sync.Mutex
+	active  bool
+	hook    func(throttled bool) // nil unless OnThrottle was called
+	release *time.Timer          // clears active once the limiter refills
+	gen     uint64               // bumped on every change, so a stale release is ignored
+}
+
+// set records whether jobs are being held back, logging and calling the
+// hook when the state changes.
+func (t *throttleState) set(throttled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.update(throttled)
+}
+
+// update is set with t.mu held.
+func (t *throttleState) update(throttled bool) {
+	t.gen++
+	if t.release != nil {
+		t.release.Stop()
+		t.release = nil
+	}
+	if t.active == throttled {
+		return
+	}
+	t.active = throttled
+
+	if throttled {
+		slog.Warn("⏳ Consumer throttled by rate limit")
+	} else {
+		slog.Info("Consumer rate limit released")
+	}
+	if t.hook != nil {
+		t.hook(throttled)
+	}
+}
+
+// releaseAfter clears the state after d unless it is set again first. Once
+// a token has refilled the next job is admitted without waiting, so nothing
+// is held back anymore even if no job arrives to observe it.
+func (t *throttleState) releaseAfter(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.release != nil {
+		t.release.Stop()
+	}
+	gen := t.gen
+	t.release = time.AfterFunc(d, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.gen == gen {
+			t.update(false)
+		}
+	})
+}
+
+// get reports whether jobs are being held back.
+func (t *throttleState) get() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.active
+}
+
+// setHook registers fn to be called on every change.
+func (t *throttleState) setHook(fn func(throttled bool)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hook = fn
+}
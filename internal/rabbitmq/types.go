@@ -1,38 +1,143 @@
 // Package rabbitmq provides types for RabbitMQ message handling.
 package rabbitmq
 
+import (
+	"strings"
+	"time"
+)
+
 // TranscriptionRequest represents an incoming transcription job from RabbitMQ.
 type TranscriptionRequest struct {
-	AttachmentID    int    `json:"attachment_id"`
-	AudioFilePath   string `json:"audio_file_path"`
-	Language        string `json:"language,omitempty"`
-	ImportBatchID   *int   `json:"import_batch_id,omitempty"`
-	RetryCount      int    `json:"retry_count,omitempty"`
+	AttachmentID  int    `json:"attachment_id"`
+	AudioFilePath string `json:"audio_file_path"`
+	Language      string `json:"language,omitempty"`
+	ImportBatchID *int   `json:"import_batch_id,omitempty"`
+	RetryCount    int    `json:"retry_count,omitempty"`
+	Model         string `json:"model,omitempty"`
+	ModelOverride string `json:"model_override,omitempty"` // Must be in the allowed models
+	Priority      uint8  `json:"priority,omitempty"`       // 0 (lowest) to MaxPriority
+	CallbackURL   string `json:"callback_url,omitempty"`   // Also POST the result here
+	RoutingKey    string `json:"-"`                        // Key the request was published with
+
+	// ResultExpiresAt discards the result if it is not consumed by then,
+	// overriding the producer's result TTL. Zero uses the producer's TTL.
+	ResultExpiresAt time.Time `json:"result_expires_at,omitempty"`
+
+	// SubmittedAt is when the publisher submitted the job. It is kept
+	// across retries, so the result latency covers the whole pipeline.
+	SubmittedAt time.Time `json:"submitted_at,omitempty"`
 }
 
 // TranscriptionResult represents the result sent back to RabbitMQ.
 type TranscriptionResult struct {
-	AttachmentID     int     `json:"attachment_id"`
-	Texto            string  `json:"texto"`
-	Duration         float64 `json:"duration"`
-	Model            string  `json:"model"`
-	Success          bool    `json:"success"`
-	ImportBatchID    *int    `json:"import_batch_id,omitempty"`
-	ErrorMessage     string  `json:"error_message,omitempty"`
-	ProcessingTimeMs int64   `json:"processing_time_ms,omitempty"`
+	AttachmentID     int       `json:"attachment_id"`
+	Texto            string    `json:"texto"`
+	Duration         float64   `json:"duration"`
+	Model            string    `json:"model"`
+	Language         string    `json:"language,omitempty"` // requested language; empty if auto-detected
+	Success          bool      `json:"success"`
+	ImportBatchID    *int      `json:"import_batch_id,omitempty"`
+	ErrorMessage     string    `json:"error_message,omitempty"`
+	ErrorCode        string    `json:"error_code,omitempty"` // One of the ErrCode constants
+	ProcessingTimeMs int64     `json:"processing_time_ms,omitempty"`
+	IsSilent         bool      `json:"is_silent,omitempty"`
+	Segments         []Segment `json:"segments,omitempty"`
+
+	// Set when the result was split to respect the max message size:
+	// chunks are numbered from 1 and share the AttachmentID
+	ChunkIndex  int    `json:"chunk_index,omitempty"`
+	TotalChunks int    `json:"total_chunks,omitempty"`
+	Warning     string `json:"warning,omitempty"` // Set when the result was truncated
+
+	// Time spent in each phase of a successful job. PublishMs is measured
+	// after the result is published, so it is only set in the callback.
+	QueueWaitMs  int64 `json:"queue_wait_ms,omitempty"`
+	ValidationMs int64 `json:"validation_ms,omitempty"`
+	ExecutionMs  int64 `json:"execution_ms,omitempty"`
+	PublishMs    int64 `json:"publish_ms,omitempty"`
+
+	// ProcessedAt is when the result was published. LatencyMs is the time
+	// since the request's SubmittedAt, zero if the publisher did not set it.
+	ProcessedAt time.Time `json:"processed_at,omitempty"`
+	LatencyMs   int64     `json:"latency_ms,omitempty"`
+
+	// DebugInfo is the Python stderr of the successful attempt, set only
+	// when DEBUG_RESPONSES is enabled
+	DebugInfo string `json:"debug_info,omitempty"`
+
+	// ExpiresAt is the request's ResultExpiresAt, applied as the per-message
+	// expiration when the result is published
+	ExpiresAt time.Time `json:"-"`
+}
+
+// Error codes set in TranscriptionResult.ErrorCode and DeadLetterMessage.ErrorCode,
+// so consumers can handle failures without matching the error message.
+const (
+	ErrCodeFileNotFound      = "FILE_NOT_FOUND"
+	ErrCodeUnsupportedFormat = "UNSUPPORTED_FORMAT"
+	ErrCodeFileTooLarge      = "FILE_TOO_LARGE"
+	ErrCodePythonError       = "PYTHON_ERROR"
+	ErrCodeTimeout           = "TIMEOUT"
+	ErrCodeMaxRetries        = "MAX_RETRIES_EXCEEDED"
+	ErrCodeInvalidRequest    = "INVALID_REQUEST"   // Model or path not allowed
+	ErrCodeDuplicate         = "DUPLICATE"         // Attachment already in flight
+	ErrCodeMessageTooLarge   = "MESSAGE_TOO_LARGE" // Request body over MAX_INBOUND_MESSAGE_SIZE_BYTES
+)
+
+// Segment is a timed span of the transcription, in seconds from the start.
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	Words []Word  `json:"words,omitempty"`
+}
+
+// Word is a single timed word within a Segment.
+type Word struct {
+	Word        string  `json:"word"`
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Probability float64 `json:"probability"`
+}
+
+// SegmentsText joins the text of every segment into a single string.
+func SegmentsText(segments []Segment) string {
+	parts := make([]string, 0, len(segments))
+	for _, s := range segments {
+		if text := strings.TrimSpace(s.Text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// DeadLetterMessage is the body archived in the dead letter queue.
+type DeadLetterMessage struct {
+	Request   TranscriptionRequest `json:"request"`
+	Error     string               `json:"error"`
+	ErrorCode string               `json:"error_code,omitempty"`
+	FailedAt  time.Time            `json:"failed_at"`
 }
 
 // PythonWorkerRequest is the request sent to Python worker via stdin.
 type PythonWorkerRequest struct {
 	AudioFilePath string `json:"audio_file_path"`
 	Language      string `json:"language,omitempty"`
+	Model         string `json:"model,omitempty"`         // Overrides WHISPER_MODEL for this request
+	TraceContext  string `json:"trace_context,omitempty"` // W3C traceparent of the execute span
 }
 
 // PythonWorkerResponse is the response received from Python worker via stdout.
 type PythonWorkerResponse struct {
-	Success      bool    `json:"success"`
-	Texto        string  `json:"texto,omitempty"`
-	Duration     float64 `json:"duration,omitempty"`
-	Model        string  `json:"model,omitempty"`
-	ErrorMessage string  `json:"error_message,omitempty"`
+	Success      bool      `json:"success"`
+	Texto        string    `json:"texto,omitempty"`
+	Duration     float64   `json:"duration,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	IsSilent     bool      `json:"is_silent,omitempty"`
+	Segments     []Segment `json:"segments,omitempty"`
+
+	// Stderr holds what the process wrote to stderr during the request,
+	// captured in Go when stderr capture is enabled
+	Stderr string `json:"-"`
 }
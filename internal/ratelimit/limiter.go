@@ -0,0 +1,95 @@
+// Package ratelimit provides a minimal token bucket rate limiter.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket refilled at a fixed rate up to a burst size.
+// A Limiter with a non-positive rate never blocks.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter allowing rps events per second with the given burst.
+func NewLimiter(rps float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow consumes a token if one is available right now.
+func (l *Limiter) Allow() bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait blocks until a token is available or the context is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		l.refill(time.Now())
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds the tokens accrued since the last call. Caller must hold l.mu.
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// Interval returns the time it takes to refill one token, or zero if the
+// limiter never blocks.
+func (l *Limiter) Interval() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / l.rate)
+}
@@ -0,0 +1,252 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// exportInterval is how often queued spans are sent.
+	exportInterval = 5 * time.Second
+	// maxBatch is the number of queued spans that triggers an early export.
+	maxBatch = 512
+	// queueSize bounds the spans waiting for export; extra spans are dropped.
+	queueSize = 4096
+)
+
+// exporter is the active OTLP exporter, nil while tracing is disabled.
+var exporter atomic.Pointer[otlpExporter]
+
+// otlpExporter sends spans to an OTLP/HTTP collector using the JSON encoding.
+type otlpExporter struct {
+	url     string
+	service string
+	client  *http.Client
+	queue   chan *Span
+	dropped atomic.Int64
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Setup enables span export to the OTLP/HTTP collector at endpoint
+// (e.g. http://tempo:4318). An empty endpoint leaves tracing disabled: trace
+// context is still propagated but no span is exported. The returned function
+// flushes pending spans and stops the exporter.
+func Setup(endpoint, serviceName string) func(context.Context) error {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	e := &otlpExporter{
+		url:     strings.TrimRight(endpoint, "/") + "/v1/traces",
+		service: serviceName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan *Span, queueSize),
+		done:    make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	exporter.Store(e)
+
+	slog.Info("🔭 Tracing enabled", slog.String("endpoint", e.url), slog.String("service", serviceName))
+
+	return func(ctx context.Context) error {
+		exporter.CompareAndSwap(e, nil)
+		close(e.done)
+
+		stopped := make(chan struct{})
+		go func() {
+			e.wg.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// enqueue queues span for export, dropping it if the queue is full.
+func (e *otlpExporter) enqueue(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// run exports queued spans every exportInterval or when a batch is full.
+func (e *otlpExporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, maxBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			slog.Warn("⚠️  Span export failed", slog.Int("spans", len(batch)), slog.Any("error", err))
+		}
+		if dropped := e.dropped.Swap(0); dropped > 0 {
+			slog.Warn("⚠️  Spans dropped, export queue full", slog.Int64("spans", dropped))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= maxBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// export POSTs spans as an OTLP ExportTraceServiceRequest.
+func (e *otlpExporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post spans: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON encoding of the trace export request.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"` // 0 unset, 2 error
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// request builds the export request for spans.
+func (e *otlpExporter) request(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+			Name:              s.name,
+			Kind:              1, // internal
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parent != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		for key, value := range s.attrs {
+			span.Attributes = append(span.Attributes, attribute(key, value))
+		}
+		if s.err != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.err}
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{attribute("service.name", e.service)}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "whisper-local"}, Spans: encoded}},
+	}}}
+}
+
+// attribute encodes a key/value pair as an OTLP attribute.
+func attribute(key string, value interface{}) otlpAttribute {
+	var v otlpValue
+	switch x := value.(type) {
+	case bool:
+		v.BoolValue = &x
+	case int:
+		s := strconv.Itoa(x)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &x
+	case string:
+		v.StringValue = &x
+	default:
+		s := fmt.Sprint(x)
+		v.StringValue = &s
+	}
+	return otlpAttribute{Key: key, Value: v}
+}
@@ -0,0 +1,173 @@
+// Package telemetry provides distributed tracing with W3C trace context
+// propagation and an OTLP/HTTP exporter.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C header carrying the trace context.
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats sc as a W3C traceparent value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a W3C traceparent value.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if n, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil || n != len(sc.TraceID) || len(parts[1]) != 32 {
+		return SpanContext{}, false
+	}
+	if n, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil || n != len(sc.SpanID) || len(parts[2]) != 16 {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, sc.IsValid()
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying sc as the parent of
+// spans started from it.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx, if any.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// Traceparent returns the W3C traceparent of the span in ctx, or "".
+func Traceparent(ctx context.Context) string {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.Traceparent()
+	}
+	return ""
+}
+
+// Extract returns ctx with the trace context found in headers, such as the
+// headers of an AMQP message.
+func Extract(ctx context.Context, headers map[string]interface{}) context.Context {
+	value, _ := headers[TraceparentHeader].(string)
+	if sc, ok := ParseTraceparent(value); ok {
+		return ContextWithSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// Inject writes the trace context of ctx into headers.
+func Inject(ctx context.Context, headers map[string]interface{}) {
+	if tp := Traceparent(ctx); tp != "" {
+		headers[TraceparentHeader] = tp
+	}
+}
+
+// Span is a timed operation within a trace. A Span is exported when it ends
+// if tracing is enabled and the trace is sampled.
+type Span struct {
+	mu     sync.Mutex
+	name   string
+	sc     SpanContext
+	parent [8]byte
+	start  time.Time
+	end    time.Time
+	attrs  map[string]interface{}
+	err    string
+	ended  bool
+}
+
+// Start starts a span named name as a child of the span in ctx, or as the
+// root of a new trace, and returns a context carrying it.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	span := &Span{name: name, start: time.Now()}
+	if parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		rand.Read(span.sc.TraceID[:])
+		span.sc.Sampled = true
+	}
+	rand.Read(span.sc.SpanID[:])
+
+	return ContextWithSpanContext(ctx, span.sc), span
+}
+
+// SpanContext returns the identity of s.
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// SetAttribute records a string, bool, integer or float attribute.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attrs == nil {
+		s.attrs = make(map[string]interface{})
+	}
+	s.attrs[key] = value
+}
+
+// RecordError marks s as failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes s and queues it for export. Calls after the first are no-ops.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if e := exporter.Load(); e != nil && s.sc.Sampled {
+		e.enqueue(s)
+	}
+}
@@ -0,0 +1,81 @@
+// Package validator provides file validation utilities.
+package validator
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FfprobePath is the ffprobe binary used by ProbeAudioDuration.
+var FfprobePath = "ffprobe"
+
+// probeTimeout bounds a single ffprobe invocation.
+const probeTimeout = 30 * time.Second
+
+// AudioTooLongError is returned when audio exceeds the configured duration limit.
+type AudioTooLongError struct {
+	Path   string
+	Actual time.Duration
+	Max    time.Duration
+}
+
+// Error implements the error interface.
+func (e *AudioTooLongError) Error() string {
+	return fmt.Sprintf("audio exceeds %s limit (actual: %s)", e.Max, e.Actual.Round(time.Second))
+}
+
+// ProbeAudioDuration returns the duration of an audio file as reported by ffprobe.
+func ProbeAudioDuration(path string) (time.Duration, error) {
+	return ProbeAudioDurationWithContext(context.Background(), path)
+}
+
+// ProbeAudioDurationWithContext is like ProbeAudioDuration, but also kills
+// ffprobe once ctx is done.
+func ProbeAudioDurationWithContext(ctx context.Context, path string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, FfprobePath,
+		"-v", "quiet",
+		"-show_entries", "format=duration",
+		"-of", "csv=p=0",
+		path,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run ffprobe: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ffprobe duration %q: %w", strings.TrimSpace(string(out)), err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ValidateAudioDuration returns an *AudioTooLongError if the audio is longer than max.
+// A non-positive max disables the check. Probe failures are returned as-is.
+func ValidateAudioDuration(path string, max time.Duration) error {
+	return ValidateAudioDurationWithContext(context.Background(), path, max)
+}
+
+// ValidateAudioDurationWithContext is like ValidateAudioDuration, but the
+// probe also stops once ctx is done.
+func ValidateAudioDurationWithContext(ctx context.Context, path string, max time.Duration) error {
+	if max <= 0 {
+		return nil
+	}
+
+	duration, err := ProbeAudioDurationWithContext(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if duration > max {
+		return &AudioTooLongError{Path: path, Actual: duration, Max: max}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package validator_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"whisper-local/internal/validator"
+)
+
+// useFfprobe points validator.FfprobePath at path for the rest of the test.
+func useFfprobe(t *testing.T, path string) {
+	t.Helper()
+	previous := validator.FfprobePath
+	validator.FfprobePath = path
+	t.Cleanup(func() { validator.FfprobePath = previous })
+}
+
+// fakeFfprobe installs a shell script as ffprobe that runs body.
+func fakeFfprobe(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffprobe is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	useFfprobe(t, path)
+}
+
+// writeWAV writes seconds of 8 kHz 16-bit mono silence as a WAV file and
+// returns its path.
+func writeWAV(t *testing.T, seconds int) string {
+	t.Helper()
+	data := make([]byte, seconds*16000)
+	header := []byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00data\x00\x00\x00\x00")
+	putUint32 := func(b []byte, v int) {
+		b[0], b[1], b[2], b[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+	}
+	putUint32(header[4:8], 36+len(data))
+	putUint32(header[40:44], len(data))
+
+	path := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(path, append(header, data...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateAudioDuration_FakeFfprobe(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		max       time.Duration
+		tooLong   bool
+		probeFail bool
+	}{
+		{"within the limit", "echo 12.5", time.Minute, false, false},
+		{"exactly the limit", "echo 60.000000", time.Minute, false, false},
+		{"over the limit", "echo 90.25", time.Minute, true, false},
+		{"limit disabled", "exit 1", 0, false, false},
+		{"ffprobe fails", "exit 1", time.Minute, false, true},
+		{"unparsable duration", "echo N/A", time.Minute, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeFfprobe(t, tt.script)
+
+			err := validator.ValidateAudioDuration("/audio/a.mp3", tt.max)
+			var tooLong *validator.AudioTooLongError
+			switch {
+			case tt.tooLong:
+				if !errors.As(err, &tooLong) {
+					t.Fatalf("err = %v, want *AudioTooLongError", err)
+				}
+				if tooLong.Max != tt.max || tooLong.Actual != 90250*time.Millisecond {
+					t.Errorf("error = %+v", tooLong)
+				}
+			case tt.probeFail:
+				if err == nil || errors.As(err, &tooLong) {
+					t.Errorf("err = %v, want a probe error", err)
+				}
+			default:
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAudioDuration_WAV(t *testing.T) {
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		t.Skip("ffprobe not installed")
+	}
+	useFfprobe(t, ffprobe)
+	path := writeWAV(t, 2)
+
+	if err := validator.ValidateAudioDuration(path, 5*time.Second); err != nil {
+		t.Errorf("2 s WAV with a 5 s limit: %v", err)
+	}
+
+	var tooLong *validator.AudioTooLongError
+	if err := validator.ValidateAudioDuration(path, time.Second); !errors.As(err, &tooLong) {
+		t.Errorf("2 s WAV with a 1 s limit: err = %v, want *AudioTooLongError", err)
+	} else if tooLong.Actual.Round(100*time.Millisecond) != 2*time.Second {
+		t.Errorf("actual duration = %s, want 2s", tooLong.Actual)
+	}
+
+	// Not audio at all: ffprobe fails, which is not reported as too long
+	broken := filepath.Join(t.TempDir(), "broken.wav")
+	if err := os.WriteFile(broken, []byte("not a wav file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.ValidateAudioDuration(broken, time.Second); err == nil || errors.As(err, &tooLong) {
+		t.Errorf("broken WAV: err = %v, want a probe error", err)
+	}
+}
@@ -2,42 +2,117 @@
 package validator
 
 import (
+	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
+	"time"
 )
 
-// SupportedAudioFormats lists all supported audio extensions.
+// SupportedAudioFormats lists the audio extensions accepted by
+// ValidateAudioExtension. Validator takes its list from SUPPORTED_EXTENSIONS,
+// whose default matches this one.
 var SupportedAudioFormats = []string{
 	".opus", ".mp3", ".wav", ".m4a", ".ogg", ".flac", ".aac", ".wma",
 }
 
-// FileExists checks if a file exists at the given path.
-func FileExists(path string) bool {
+// FileInfo is the metadata of a regular file, read once per job.
+type FileInfo struct {
+	Path        string
+	SizeBytes   int64
+	ModTime     time.Time
+	Permissions os.FileMode
+	IsReadable  bool // whether the file could be opened; accounts for ACLs, unlike Permissions
+}
+
+// StatFile returns the metadata of the regular file at path with a single
+// stat. IsReadable is found by opening the file. It returns an error if the
+// file does not exist or is a directory.
+func StatFile(path string) (*FileInfo, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return !info.IsDir()
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	readable := false
+	if f, err := os.Open(path); err == nil {
+		readable = true
+		f.Close()
+	}
+
+	return &FileInfo{
+		Path:        path,
+		SizeBytes:   info.Size(),
+		ModTime:     info.ModTime(),
+		Permissions: info.Mode().Perm(),
+		IsReadable:  readable,
+	}, nil
+}
+
+// FileExists checks if a file exists at the given path.
+//
+// Deprecated: use StatFile, which also returns the metadata.
+func FileExists(path string) bool {
+	_, err := StatFile(path)
+	return err == nil
 }
 
 // ValidateAudioExtension checks if the file has a supported audio extension.
+//
+// Deprecated: use Validator.ValidateExtension, whose extensions are configurable.
 func ValidateAudioExtension(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	
-	for _, validExt := range SupportedAudioFormats {
-		if ext == validExt {
-			return true
-		}
-	}
-	return false
+	return (&Validator{AllowedExtensions: SupportedAudioFormats}).ValidateExtension(path)
 }
 
 // GetFileSize returns the size of a file in bytes.
+//
+// Deprecated: use StatFile and FileInfo.SizeBytes.
 func GetFileSize(path string) (int64, error) {
-	info, err := os.Stat(path)
+	info, err := StatFile(path)
 	if err != nil {
 		return 0, err
 	}
-	return info.Size(), nil
+	return info.SizeBytes, nil
+}
+
+// bytesPerMB is the number of bytes in one megabyte as used by MAX_FILE_SIZE_MB.
+const bytesPerMB = 1024 * 1024
+
+// FileTooLargeError is returned when a file exceeds the configured size limit.
+type FileTooLargeError struct {
+	Path     string
+	ActualMB float64
+	MaxMB    int
+}
+
+// Error implements the error interface.
+func (e *FileTooLargeError) Error() string {
+	return fmt.Sprintf("file exceeds %d MB limit (actual: %.1f MB)", e.MaxMB, e.ActualMB)
+}
+
+// ValidateFileSize returns a *FileTooLargeError if the file is larger than maxMB.
+// A non-positive maxMB disables the check.
+func ValidateFileSize(path string, maxMB int) error {
+	if maxMB <= 0 {
+		return nil
+	}
+
+	info, err := StatFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	return info.ValidateSize(maxMB)
+}
+
+// ValidateSize is ValidateFileSize for an already stat'ed file.
+func (f *FileInfo) ValidateSize(maxMB int) error {
+	if maxMB > 0 && f.SizeBytes > int64(maxMB)*bytesPerMB {
+		return &FileTooLargeError{
+			Path:     f.Path,
+			ActualMB: float64(f.SizeBytes) / bytesPerMB,
+			MaxMB:    maxMB,
+		}
+	}
+	return nil
 }
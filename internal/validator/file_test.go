@@ -0,0 +1,71 @@
+package validator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"whisper-local/internal/validator"
+)
+
+// writeSized creates a file of size bytes in a temporary directory and
+// returns its path.
+func writeSized(t *testing.T, size int64) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audio.mp3")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateFileSize(t *testing.T) {
+	const maxMB = 2
+	const limit = maxMB * 1024 * 1024
+	tests := []struct {
+		name     string
+		size     int64
+		maxMB    int
+		tooLarge bool
+	}{
+		{"zero bytes", 0, maxMB, false},
+		{"below the limit", limit - 1, maxMB, false},
+		{"exactly the limit", limit, maxMB, false},
+		{"one byte over", limit + 1, maxMB, true},
+		{"limit disabled", limit + 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSized(t, tt.size)
+
+			info, err := validator.StatFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, err := range map[string]error{
+				"ValidateFileSize":      validator.ValidateFileSize(path, tt.maxMB),
+				"FileInfo.ValidateSize": info.ValidateSize(tt.maxMB),
+			} {
+				var tooLarge *validator.FileTooLargeError
+				if got := errors.As(err, &tooLarge); got != tt.tooLarge {
+					t.Fatalf("%s(%d bytes, %d MB) = %v, want too large %t", name, tt.size, tt.maxMB, err, tt.tooLarge)
+				}
+				if tt.tooLarge && (tooLarge.Path != path || tooLarge.MaxMB != tt.maxMB) {
+					t.Errorf("%s: error = %+v", name, tooLarge)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFileSize_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.mp3")
+	var tooLarge *validator.FileTooLargeError
+	if err := validator.ValidateFileSize(path, 1); err == nil || errors.As(err, &tooLarge) {
+		t.Errorf("ValidateFileSize(missing) = %v, want a stat error", err)
+	}
+}
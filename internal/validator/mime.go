@@ -0,0 +1,114 @@
+// Package validator provides file validation utilities.
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is the number of leading bytes inspected for magic numbers.
+const sniffLen = 512
+
+// SupportedMIMETypes lists the detected content types accepted as audio.
+var SupportedMIMETypes = []string{
+	"audio/mpeg", "audio/wave", "audio/flac", "audio/aac", "audio/x-ms-wma",
+	"audio/aiff", "application/ogg", "video/mp4", "video/webm",
+}
+
+// extensionMIMETypes maps each supported extension to the content type it should sniff as.
+var extensionMIMETypes = map[string]string{
+	".opus": "application/ogg",
+	".ogg":  "application/ogg",
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wave",
+	".m4a":  "video/mp4",
+	".flac": "audio/flac",
+	".aac":  "audio/aac",
+	".wma":  "audio/x-ms-wma",
+}
+
+// asfHeaderGUID starts every ASF (WMA/WMV) container.
+var asfHeaderGUID = []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11}
+
+// UnsupportedMIMETypeError is returned when a file's content is not a supported audio type.
+type UnsupportedMIMETypeError struct {
+	Path     string
+	MIMEType string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedMIMETypeError) Error() string {
+	return fmt.Sprintf("unsupported content type %s", e.MIMEType)
+}
+
+// ValidateMIMEType detects the content type of a file from its magic bytes
+// and returns an *UnsupportedMIMETypeError if it is not in SupportedMIMETypes.
+//
+// Deprecated: use Validator.ValidateMIMEType, which also accepts the
+// configured extensions the sniffer does not recognise.
+func ValidateMIMEType(path string) (string, error) {
+	return validateMIMEType(path)
+}
+
+// validateMIMEType sniffs path and checks the result against SupportedMIMETypes.
+func validateMIMEType(path string) (string, error) {
+	mimeType, err := sniffFile(path)
+	if err != nil {
+		return "", err
+	}
+	for _, supported := range SupportedMIMETypes {
+		if mimeType == supported {
+			return mimeType, nil
+		}
+	}
+	return mimeType, &UnsupportedMIMETypeError{Path: path, MIMEType: mimeType}
+}
+
+// sniffFile returns the content type of the file at path from its first
+// sniffLen bytes.
+func sniffFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file header: %w", err)
+	}
+	return DetectMIMEType(header[:n]), nil
+}
+
+// DetectMIMEType returns the content type of header, recognising audio
+// formats that http.DetectContentType does not sniff.
+func DetectMIMEType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return "audio/flac"
+	case bytes.HasPrefix(header, asfHeaderGUID):
+		return "audio/x-ms-wma"
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xF6 == 0xF0:
+		return "audio/aac" // ADTS frame
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		return "audio/mpeg" // MPEG frame without ID3 tag
+	}
+
+	mimeType := http.DetectContentType(header)
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType
+}
+
+// ExpectedMIMEType returns the content type implied by the file extension,
+// or "" if the extension is not supported.
+func ExpectedMIMEType(path string) string {
+	return extensionMIMETypes[strings.ToLower(filepath.Ext(path))]
+}
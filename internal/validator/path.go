@@ -0,0 +1,68 @@
+// Package validator provides file validation utilities.
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// PathNotAllowedError is returned when a file lies outside every allowed directory.
+type PathNotAllowedError struct {
+	Path        string
+	AllowedDirs []string
+}
+
+// Error implements the error interface.
+func (e *PathNotAllowedError) Error() string {
+	return fmt.Sprintf("path %s is outside the allowed audio directories (%s)",
+		e.Path, strings.Join(e.AllowedDirs, ", "))
+}
+
+// ValidateFilePath returns a *PathNotAllowedError unless path, after
+// cleaning and resolving symlinks, is inside one of allowedDirs.
+// An empty allowedDirs disables the check. A path that does not exist is
+// checked in its cleaned form so the caller can report it as missing.
+func ValidateFilePath(path string, allowedDirs []string) error {
+	if len(allowedDirs) == 0 {
+		return nil
+	}
+
+	if !filepath.IsAbs(path) {
+		return &PathNotAllowedError{Path: path, AllowedDirs: allowedDirs}
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+		resolved = filepath.Clean(path)
+	}
+
+	for _, dir := range allowedDirs {
+		if isWithin(resolved, resolveDir(dir)) {
+			return nil
+		}
+	}
+	return &PathNotAllowedError{Path: path, AllowedDirs: allowedDirs}
+}
+
+// resolveDir cleans dir and resolves its symlinks when it exists.
+func resolveDir(dir string) string {
+	dir = filepath.Clean(dir)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return dir
+}
+
+// isWithin reports whether path is dir or lies below it.
+func isWithin(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// AudioInfo describes the first audio stream of a file as reported by ffprobe.
+type AudioInfo struct {
+	HasAudioStream bool
+	CodecName      string
+	ChannelCount   int
+	SampleRate     int
+	BitrateKbps    int
+}
+
+// NoAudioStreamError is returned when a file has no audio stream, e.g. a
+// truncated upload or a video without sound.
+type NoAudioStreamError struct {
+	Path string
+}
+
+// Error implements the error interface.
+func (e *NoAudioStreamError) Error() string {
+	return "file has no audio stream"
+}
+
+// ffprobeStreams is the subset of `ffprobe -show_streams` JSON output we read.
+// ffprobe reports sample_rate and bit_rate as strings.
+type ffprobeStreams struct {
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		Channels   int    `json:"channels"`
+		SampleRate string `json:"sample_rate"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"streams"`
+}
+
+// ProbeAudioStreams inspects the streams of path with the ffprobe binary at
+// ffprobePath. A file that ffprobe can read but that has no audio stream
+// yields HasAudioStream false and a nil error.
+func ProbeAudioStreams(path string, ffprobePath string) (*AudioInfo, error) {
+	return ProbeAudioStreamsWithContext(context.Background(), path, ffprobePath)
+}
+
+// ProbeAudioStreamsWithContext is like ProbeAudioStreams, but also kills
+// ffprobe once ctx is done.
+func ProbeAudioStreamsWithContext(ctx context.Context, path string, ffprobePath string) (*AudioInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_streams",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ffprobe: %w", err)
+	}
+
+	var probe ffprobeStreams
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe streams: %w", err)
+	}
+
+	info := &AudioInfo{}
+	for _, stream := range probe.Streams {
+		if stream.CodecType != "audio" {
+			continue
+		}
+		info.HasAudioStream = true
+		info.CodecName = stream.CodecName
+		info.ChannelCount = stream.Channels
+		info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+		if bitrate, err := strconv.Atoi(stream.BitRate); err == nil {
+			info.BitrateKbps = bitrate / 1000
+		}
+		break
+	}
+	return info, nil
+}
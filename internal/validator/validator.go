@@ -0,0 +1,43 @@
+// Package validator provides file validation utilities.
+package validator
+
+import (
+	"path/filepath"
+	"strings"
+
+	"whisper-local/internal/config"
+)
+
+// Validator checks audio files against a configurable set of extensions.
+type Validator struct {
+	AllowedExtensions []string // lower case, with the leading dot
+}
+
+// NewValidator returns a Validator accepting cfg.SupportedExtensions.
+func NewValidator(cfg *config.Config) *Validator {
+	return &Validator{AllowedExtensions: cfg.SupportedExtensions}
+}
+
+// ValidateExtension reports whether path has one of AllowedExtensions,
+// ignoring case.
+func (v *Validator) ValidateExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, allowed := range v.AllowedExtensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateMIMEType detects the content type of path from its magic bytes.
+// Extensions with a known content type must sniff as one of
+// SupportedMIMETypes, or an *UnsupportedMIMETypeError is returned. Other
+// allowed extensions are codecs the sniffer does not recognise, so their
+// content type is only reported and left to ffprobe and Python.
+func (v *Validator) ValidateMIMEType(path string) (string, error) {
+	if ExpectedMIMEType(path) == "" {
+		return sniffFile(path)
+	}
+	return validateMIMEType(path)
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"whisper-local/internal/rabbitmq"
+)
+
+// callbackSender POSTs results to TranscriptionRequest.CallbackURL.
+// A semaphore bounds the number of callbacks in flight.
+type callbackSender struct {
+	client *http.Client
+	sem    chan struct{}
+}
+
+// newCallbackSender creates a sender with the given per-request timeout and
+// concurrency limit.
+func newCallbackSender(timeout time.Duration, concurrency int) *callbackSender {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &callbackSender{
+		client: &http.Client{Timeout: timeout},
+		sem:    make(chan struct{}, concurrency),
+	}
+}
+
+// post sends result as JSON to callbackURL.
+func (s *callbackSender) post(callbackURL string, result rabbitmq.TranscriptionResult) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid callback URL %q", callbackURL)
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	resp, err := s.client.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post callback: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sendCallback delivers result to the request's callback URL in the
+// background. It blocks while the concurrency limit is reached. Failures are
+// only logged: the result published to RabbitMQ is the source of truth.
+func (p *Pool) sendCallback(logger *slog.Logger, callbackURL string, result rabbitmq.TranscriptionResult) {
+	p.callbacks.sem <- struct{}{}
+	p.wg.Add(1) // Drain waits for in-flight callbacks
+
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.callbacks.sem }()
+
+		if err := p.callbacks.post(callbackURL, result); err != nil {
+			logger.Warn("⚠️  Callback failed", slog.String("callback_url", callbackURL), slog.Any("error", err))
+			return
+		}
+		logger.Debug("Callback delivered", slog.String("callback_url", callbackURL))
+	}()
+}
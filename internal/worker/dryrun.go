@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+
+	"whisper-local/internal/rabbitmq"
+)
+
+// DryRunText is the transcription of every job processed in dry-run mode.
+const DryRunText = "[dry-run]"
+
+// DryRunExecutor is the Executor used with DRY_RUN: it answers every
+// request with DryRunText and never starts Python. It reports its workers
+// as alive so the readiness probe passes without a Python environment.
+type DryRunExecutor struct {
+	workers int
+}
+
+var _ Executor = (*DryRunExecutor)(nil)
+
+// NewDryRunExecutor returns a DryRunExecutor standing in for workers processes.
+func NewDryRunExecutor(workers int) *DryRunExecutor {
+	return &DryRunExecutor{workers: workers}
+}
+
+// ExecuteWithContext returns the dry-run response for request.
+func (e *DryRunExecutor) ExecuteWithContext(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+	return dryRunResponse(request), nil
+}
+
+// Counts reports every worker as alive and idle.
+func (e *DryRunExecutor) Counts() (total, alive, busy int) {
+	return e.workers, e.workers, 0
+}
+
+// Stats returns the number of stand-in workers.
+func (e *DryRunExecutor) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"dry_run": true,
+		"workers": e.workers,
+	}
+}
+
+// dryRunResponse is the synthetic successful response to request: DryRunText
+// with no audio duration, reported with the requested model if any.
+func dryRunResponse(request rabbitmq.TranscriptionRequest) *rabbitmq.PythonWorkerResponse {
+	return &rabbitmq.PythonWorkerResponse{
+		Success:  true,
+		Texto:    DryRunText,
+		Duration: 0,
+		Model:    request.ModelOverride,
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrProcessDead is returned by Execute when the Python process could not be
+// written to or read from, usually because it crashed mid-request.
+var ErrProcessDead = errors.New("python process died")
+
+// ErrProcessTimeout is returned by Execute when the job deadline expired and
+// the Python process was killed.
+var ErrProcessTimeout = errors.New("python process timed out")
+
+// ErrNoWorkers is returned by Execute when no Python process became
+// available before the job deadline or shutdown. Pool requeues the job
+// without counting a retry.
+var ErrNoWorkers = errors.New("no available workers")
+
+// nonRetryablePythonErrors are substrings of Python error messages caused by
+// the audio itself, which fail the same way on every attempt.
+var nonRetryablePythonErrors = []string{
+	"File not found:",
+	"Validation error:",
+	"UnsupportedAudio",
+}
+
+// ErrPythonError is returned by Execute when the Python worker answered with
+// success false.
+type ErrPythonError struct {
+	Message string
+	Stderr  string // captured stderr of the request, empty unless enabled
+}
+
+// Error implements the error interface.
+func (e *ErrPythonError) Error() string {
+	return e.Message
+}
+
+// Retryable reports whether another attempt may succeed.
+func (e *ErrPythonError) Retryable() bool {
+	for _, s := range nonRetryablePythonErrors {
+		if strings.Contains(e.Message, s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"whisper-local/internal/rabbitmq"
+)
+
+// Executor runs transcription requests for a Pool. ProcessPool is the
+// production implementation; workertest.MockExecutor replaces it in tests.
+type Executor interface {
+	ExecuteWithContext(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error)
+	Stats() map[string]interface{}
+}
+
+// Optional Executor capabilities. Pool uses them when an executor has them
+// and skips them otherwise.
+type (
+	processCounter interface {
+		Counts() (total, alive, busy int)
+	}
+	resizer interface {
+		Resize(n int) error
+	}
+	deadRespawner interface {
+		RespawnDead()
+	}
+	shutdowner interface {
+		Shutdown()
+	}
+	processStatter interface {
+		ProcessStats() []ProcessStat
+	}
+)
+
+var _ Executor = (*ProcessPool)(nil)
+
+// executorCounts returns the process counts of executor, or zeros if it
+// does not track processes.
+func executorCounts(executor Executor) (total, alive, busy int) {
+	if counter, ok := executor.(processCounter); ok {
+		return counter.Counts()
+	}
+	return 0, 0, 0
+}
+
+// resizeExecutor resizes executor to n processes.
+func resizeExecutor(executor Executor, n int) error {
+	r, ok := executor.(resizer)
+	if !ok {
+		return fmt.Errorf("executor %T cannot be resized", executor)
+	}
+	return r.Resize(n)
+}
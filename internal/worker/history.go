@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRecentJobs is used when PoolOptions.RecentJobs is not set.
+const DefaultRecentJobs = 100
+
+// Outcomes recorded in JobRecord.Status.
+const (
+	JobSucceeded = "success"   // result published
+	JobRejected  = "rejected"  // failed validation, error result published
+	JobRetried   = "retry"     // sent to a retry queue
+	JobFailed    = "failed"    // retries exhausted, archived as dead
+	JobDuplicate = "duplicate" // attachment already in flight, archived as dead
+	JobRequeued  = "requeued"  // publishing failed, delivery returned to the queue
+	JobPanicked  = "panic"     // processing panicked, delivery returned to the queue
+)
+
+// JobRecord describes a job the pool finished handling.
+type JobRecord struct {
+	AttachmentID int       `json:"attachment_id"`
+	WorkerID     int       `json:"worker_id"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Status       string    `json:"status"`
+	Model        string    `json:"model,omitempty"`
+	Duration     float64   `json:"duration,omitempty"` // audio seconds, known for successful jobs
+	QueueWaitMs  int64     `json:"queue_wait_ms"`
+}
+
+// jobHistory keeps the most recent JobRecords in a ring buffer.
+type jobHistory struct {
+	mu      sync.RWMutex
+	records []*JobRecord
+	next    int // slot overwritten by the next add
+	full    bool
+}
+
+// newJobHistory returns a history holding up to size records.
+func newJobHistory(size int) *jobHistory {
+	return &jobHistory{records: make([]*JobRecord, size)}
+}
+
+// add stores record, evicting the oldest one when the buffer is full.
+func (h *jobHistory) add(record *JobRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// recent returns up to n records, newest first.
+func (h *jobHistory) recent(n int) []JobRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stored := h.next
+	if h.full {
+		stored = len(h.records)
+	}
+	n = min(n, stored)
+
+	records := make([]JobRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		slot := (h.next - i + len(h.records)) % len(h.records)
+		records = append(records, *h.records[slot])
+	}
+	return records
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"whisper-local/internal/rabbitmq"
+)
+
+// LocalFallbackModel is the model reported for jobs transcribed by a
+// LocalExecutor.
+const LocalFallbackModel = "local"
+
+// ErrNoLocalExecutor is returned by NullLocalExecutor.
+var ErrNoLocalExecutor = errors.New("no local transcription available")
+
+// LocalExecutor transcribes audio inside the orchestrator, e.g. with a
+// Go-native library. A ProcessPool calls it when no Python process can be
+// acquired; it returns the text and the audio duration in seconds.
+type LocalExecutor interface {
+	Transcribe(path, language string) (string, float64, error)
+}
+
+// NullLocalExecutor is a LocalExecutor that always fails, so jobs fail as
+// they would without a fallback.
+type NullLocalExecutor struct{}
+
+// Transcribe returns ErrNoLocalExecutor.
+func (NullLocalExecutor) Transcribe(path, language string) (string, float64, error) {
+	return "", 0, ErrNoLocalExecutor
+}
+
+// SetLocalExecutor installs the fallback used when no Python process is
+// available. Nil, the default, disables the fallback.
+func (p *ProcessPool) SetLocalExecutor(local LocalExecutor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.local = local
+}
+
+// localExecutor returns the configured fallback, or nil.
+func (p *ProcessPool) localExecutor() LocalExecutor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.local
+}
+
+// executeLocal transcribes request with local after acquiring a Python
+// process failed with acquireErr. If local fails too, both errors are
+// returned so the job is retried as before.
+func (p *ProcessPool) executeLocal(local LocalExecutor, request rabbitmq.TranscriptionRequest, acquireErr error) (*rabbitmq.PythonWorkerResponse, error) {
+	slog.Warn("⚠️  No Python process available, transcribing locally",
+		slog.Bool("fallback", true),
+		slog.Int("attachment_id", request.AttachmentID),
+		slog.Any("error", acquireErr))
+	p.localFallbacks.Add(1)
+
+	texto, duration, err := local.Transcribe(request.AudioFilePath, request.Language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire process: %w; local fallback failed: %w", acquireErr, err)
+	}
+	return &rabbitmq.PythonWorkerResponse{
+		Success:  true,
+		Texto:    texto,
+		Duration: duration,
+		Model:    LocalFallbackModel,
+	}, nil
+}
@@ -2,177 +2,1008 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"whisper-local/internal/metrics"
 	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/ratelimit"
+	"whisper-local/internal/telemetry"
 	"whisper-local/internal/validator"
 )
 
-// Pool manages concurrent job processing using a Python process pool.
+// DefaultPool is the process pool key used when a request has no matching model.
+const DefaultPool = "default"
+
+// queueWarnRatio is the fill level of the job buffer that Submit warns about.
+const queueWarnRatio = 0.8
+
+// Pool manages concurrent job processing using Python process pools.
 type Pool struct {
-	processPool *ProcessPool
-	producer    *rabbitmq.Producer
-	jobs        chan rabbitmq.Job
-	wg          sync.WaitGroup
-	shutdown    chan struct{}
-	numWorkers  int
+	processPools atomic.Pointer[map[string]Executor] // replaced by SwapProcessPool
+	producer     *rabbitmq.Producer
+	jobs         chan rabbitmq.Job
+	wg           sync.WaitGroup
+	shutdown     chan struct{}
+	stop         chan struct{} // each receive retires one worker
+	mu           sync.Mutex
+	submitMu     sync.RWMutex // guards draining and the close of jobs
+	draining     bool
+	closeJobs    sync.Once
+	queueHigh    atomic.Bool // queue above queueWarnRatio, warned once per crossing
+	numWorkers   int
+	nextID       int
+	jobTimeout   time.Duration
+	maxFileMB    int
+	maxDuration  time.Duration
+	sampleRate   int
+	allowedDirs  []string
+	validator    *validator.Validator
+	models       map[string]bool // allowed ModelOverride values
+	callbacks    *callbackSender
+	inflight     sync.Map // AttachmentID of every job being processed
+	duplicates   atomic.Int64
+	panicCount   atomic.Int64
+	processing   atomic.Int64
+	completed    atomic.Int64
+	failed       atomic.Int64
+	timings      phaseTimings
+	recentJobs   *jobHistory
+	admission    *ratelimit.Limiter // nil when JobsPerSecond is not set
+	maxQueueAge  time.Duration
+	dryRun       bool
+	supervisor   *WorkerSupervisor
+	startedAt    time.Time
+
+	paused         atomic.Bool
+	pauseMu        sync.Mutex
+	resume         chan struct{} // closed by Resume; nil while running
+	pausedAt       time.Time
+	pauseWarnAfter time.Duration
+}
+
+// PoolOptions configures a Pool.
+type PoolOptions struct {
+	NumWorkers    int           // Worker goroutines, normally the total process count
+	JobBuffer     int           // Jobs buffered ahead of the workers; zero means NumWorkers*2
+	JobTimeout    time.Duration // Bounds each job up to the end of its Python execution; zero disables the deadline
+	MaxFileSizeMB int           // Files above this size are rejected before reaching Python
+	MaxDuration   time.Duration // Audio longer than this is rejected before reaching Python
+	AllowedDirs   []string      // Audio paths must resolve inside one of these; empty allows any
+	AllowedModels []string      // Accepted TranscriptionRequest.ModelOverride values
+	SampleRate    int           // AUDIO_SAMPLE_RATE; other rates are logged before resampling
+	RecentJobs    int           // Finished jobs kept for RecentJobs; zero means DefaultRecentJobs
+	JobsPerSecond float64       // Executions started per second across workers; zero disables the limit
+	MaxQueueAge   time.Duration // Jobs that waited longer are rejected without running; zero disables it
+	DryRun        bool          // Validate and publish a DryRunText result instead of running Python
+
+	// Validator checks audio extensions and content; nil accepts
+	// validator.SupportedAudioFormats.
+	Validator *validator.Validator
+
+	CallbackTimeout        time.Duration // Bounds each POST to a request's CallbackURL
+	MaxCallbackConcurrency int           // Callbacks in flight before workers wait
+
+	// PauseWarnAfter is how long the pool may stay paused before a warning
+	// is logged, repeated at the same interval. Zero uses DefaultPauseWarnAfter.
+	PauseWarnAfter time.Duration
+
+	// HeartbeatInterval is how often idle workers report to the
+	// WorkerSupervisor; zero uses DefaultHeartbeatInterval. A worker silent
+	// for HeartbeatTimeout is replaced; zero means twice the interval.
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+}
+
+// DefaultPauseWarnAfter is used when PoolOptions.PauseWarnAfter is not set.
+const DefaultPauseWarnAfter = 5 * time.Minute
+
+// ProcessStats holds Python process counts for one or more process pools.
+type ProcessStats struct {
+	Total int `json:"total"`
+	Alive int `json:"alive"`
+	Busy  int `json:"busy"`
+	Idle  int `json:"idle"`
+}
+
+// PoolStats holds job counters and process counts aggregated across all
+// process pools, with a per-model breakdown of the process counts.
+type PoolStats struct {
+	ProcessStats
+	ByModel map[string]ProcessStats `json:"by_model,omitempty"`
+
+	JobsQueued     int     `json:"jobs_queued"`
+	JobsProcessing int64   `json:"jobs_processing"`
+	JobsCompleted  int64   `json:"jobs_completed"`
+	JobsFailed     int64   `json:"jobs_failed"`
+	Panics         int64   `json:"panics"`
+	WorkerCount    int     `json:"worker_count"`
+	UptimeSeconds  float64 `json:"uptime_seconds"`
+	Paused         bool    `json:"paused"`
+
+	DuplicatesDropped  int64 `json:"duplicates_dropped"`
+	WorkerRestartCount int64 `json:"worker_restart_count"`
+
+	// Average time per phase of successful jobs
+	AvgQueueWaitMs  float64 `json:"avg_queue_wait_ms"`
+	AvgValidationMs float64 `json:"avg_validation_ms"`
+	AvgExecutionMs  float64 `json:"avg_execution_ms"`
+	AvgPublishMs    float64 `json:"avg_publish_ms"`
+}
+
+// phaseTimings accumulates the phase durations of successful jobs.
+type phaseTimings struct {
+	jobs       atomic.Int64
+	queueWait  atomic.Int64 // milliseconds
+	validation atomic.Int64
+	execution  atomic.Int64
+	publish    atomic.Int64
+}
+
+// record adds the phase durations of result.
+func (t *phaseTimings) record(result rabbitmq.TranscriptionResult) {
+	t.queueWait.Add(result.QueueWaitMs)
+	t.validation.Add(result.ValidationMs)
+	t.execution.Add(result.ExecutionMs)
+	t.publish.Add(result.PublishMs)
+	t.jobs.Add(1)
+}
+
+// average returns the mean of total over the recorded jobs.
+func (t *phaseTimings) average(total *atomic.Int64) float64 {
+	jobs := t.jobs.Load()
+	if jobs == 0 {
+		return 0
+	}
+	return float64(total.Load()) / float64(jobs)
 }
 
 // NewPool creates a new worker pool.
-func NewPool(processPool *ProcessPool, producer *rabbitmq.Producer, numWorkers int) *Pool {
-	return &Pool{
-		processPool: processPool,
+// processPools is keyed by model name and must contain a DefaultPool entry;
+// each is normally a *ProcessPool.
+func NewPool(processPools map[string]Executor, producer *rabbitmq.Producer, opts PoolOptions) *Pool {
+	if opts.PauseWarnAfter <= 0 {
+		opts.PauseWarnAfter = DefaultPauseWarnAfter
+	}
+	if opts.JobBuffer <= 0 {
+		opts.JobBuffer = opts.NumWorkers * 2
+	}
+	if opts.RecentJobs <= 0 {
+		opts.RecentJobs = DefaultRecentJobs
+	}
+	models := make(map[string]bool, len(opts.AllowedModels))
+	for _, model := range opts.AllowedModels {
+		models[model] = true
+	}
+	if opts.Validator == nil {
+		opts.Validator = &validator.Validator{AllowedExtensions: validator.SupportedAudioFormats}
+	}
+	var admission *ratelimit.Limiter
+	if opts.JobsPerSecond > 0 {
+		admission = ratelimit.NewLimiter(opts.JobsPerSecond, 1)
+	}
+	pool := &Pool{
 		producer:    producer,
-		jobs:        make(chan rabbitmq.Job, numWorkers*2),
+		jobs:        make(chan rabbitmq.Job, opts.JobBuffer),
 		shutdown:    make(chan struct{}),
-		numWorkers:  numWorkers,
+		stop:        make(chan struct{}),
+		numWorkers:  opts.NumWorkers,
+		jobTimeout:  opts.JobTimeout,
+		maxFileMB:   opts.MaxFileSizeMB,
+		maxDuration: opts.MaxDuration,
+		sampleRate:  opts.SampleRate,
+		allowedDirs: opts.AllowedDirs,
+		validator:   opts.Validator,
+		models:      models,
+		callbacks:   newCallbackSender(opts.CallbackTimeout, opts.MaxCallbackConcurrency),
+		recentJobs:  newJobHistory(opts.RecentJobs),
+		admission:   admission,
+		maxQueueAge: opts.MaxQueueAge,
+		dryRun:      opts.DryRun,
+		supervisor:  NewWorkerSupervisor(opts.HeartbeatInterval, opts.HeartbeatTimeout, opts.JobTimeout),
+		startedAt:   time.Now(),
+
+		pauseWarnAfter: opts.PauseWarnAfter,
 	}
+	pool.processPools.Store(&processPools)
+	return pool
+}
+
+// pools returns the executors keyed by model. The map is never modified,
+// SwapProcessPool stores a new one.
+func (p *Pool) pools() map[string]Executor {
+	return *p.processPools.Load()
+}
+
+// SwapProcessPool makes newPool the DefaultPool executor and returns the
+// previous one. Jobs already running finish on the previous executor, so
+// the caller should wait for it to be idle before shutting it down.
+func (p *Pool) SwapProcessPool(newPool *ProcessPool) Executor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := p.pools()
+	next := make(map[string]Executor, len(current))
+	for model, processPool := range current {
+		next[model] = processPool
+	}
+	next[DefaultPool] = newPool
+	p.processPools.Store(&next)
+
+	slog.Info("🔀 Default process pool swapped")
+	return current[DefaultPool]
+}
+
+// DefaultExecutor returns the DefaultPool executor currently in use.
+func (p *Pool) DefaultExecutor() Executor {
+	return p.pools()[DefaultPool]
 }
 
 // Start begins processing jobs with the configured number of workers.
 func (p *Pool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	for i := 0; i < p.numWorkers; i++ {
-		p.wg.Add(1)
-		go p.worker(i)
+		p.startWorker()
 	}
-	log.Printf("👷 %d workers ready", p.numWorkers)
+	go p.supervise()
+	slog.Info("👷 Workers ready", slog.Int("workers", p.numWorkers))
+}
+
+// startWorker launches one worker goroutine. Caller must hold p.mu.
+func (p *Pool) startWorker() {
+	id := p.nextID
+	p.nextID++
+
+	p.supervisor.register(id)
+	p.wg.Add(1)
+	go p.worker(id)
+}
+
+// Resize sets the default process pool to n processes and adjusts the
+// number of worker goroutines to match. Surplus workers exit after
+// finishing their current job.
+func (p *Pool) Resize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("worker count must be at least 1, got %d", n)
+	}
+
+	if err := resizeExecutor(p.pools()[DefaultPool], n); err != nil {
+		return err
+	}
+
+	// Model pools keep their own size; goroutines cover all pools
+	target := n
+	for model, processPool := range p.pools() {
+		if model != DefaultPool {
+			total, _, _ := executorCounts(processPool)
+			target += total
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	old := p.numWorkers
+	switch {
+	case target > old:
+		for i := old; i < target; i++ {
+			p.startWorker()
+		}
+	case target < old:
+		go p.retireWorkers(old - target)
+	}
+	p.numWorkers = target
+
+	slog.Info("👷 Workers resized", slog.Int("old", old), slog.Int("new", target))
+	return nil
+}
+
+// retireWorkers signals count workers to exit once they are idle.
+func (p *Pool) retireWorkers(count int) {
+	for i := 0; i < count; i++ {
+		select {
+		case p.stop <- struct{}{}:
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+// NumWorkers returns the current number of worker goroutines.
+func (p *Pool) NumWorkers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.numWorkers
 }
 
 // Submit adds a job to the processing queue.
+// Once the pool is draining, or when it shuts down while Submit waits for
+// room in the queue, the delivery is requeued instead.
 func (p *Pool) Submit(job rabbitmq.Job) {
-	p.jobs <- job
+	p.submitMu.RLock()
+	defer p.submitMu.RUnlock()
+
+	if p.draining {
+		slog.Info("⏸️  Draining, requeueing job", slog.Int("attachment_id", job.Request.AttachmentID))
+		job.Delivery.Nack(false, true)
+		return
+	}
+
+	// Workers stop taking jobs on shutdown, so a full queue would never
+	// free up, and Shutdown waits for submitMu
+	select {
+	case p.jobs <- job:
+	case <-p.shutdown:
+		slog.Info("⏸️  Shutting down, requeueing job", slog.Int("attachment_id", job.Request.AttachmentID))
+		job.Delivery.Nack(false, true)
+		return
+	}
+	depth := p.QueueDepth()
+	metrics.QueueDepth.Set(float64(depth))
+
+	// Warn once each time the buffer crosses the threshold, before it fills
+	// and Submit starts blocking the consumer
+	high := float64(depth) > queueWarnRatio*float64(cap(p.jobs))
+	if high && !p.queueHigh.Swap(true) {
+		slog.Warn("⚠️  Job queue almost full", slog.Int("queued", depth), slog.Int("capacity", cap(p.jobs)))
+	} else if !high {
+		p.queueHigh.Store(false)
+	}
+}
+
+// QueueDepth returns the number of jobs waiting for a worker.
+func (p *Pool) QueueDepth() int {
+	return len(p.jobs)
 }
 
-// worker processes jobs from the queue.
+// Drain stops accepting new jobs and waits for queued and in-flight jobs
+// to finish. It returns ctx.Err() if the context expires first.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.Resume() // Parked workers must finish the queued jobs
+
+	p.submitMu.Lock()
+	p.draining = true
+	p.closeJobs.Do(func() { close(p.jobs) })
+	p.submitMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("✅ Worker pool drained")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// worker processes jobs from the queue, beating its heartbeat while idle.
+// It exits without retiring only if it was replaced by the supervisor.
 func (p *Pool) worker(id int) {
 	defer p.wg.Done()
 
+	heartbeat := time.NewTicker(p.supervisor.interval)
+	defer heartbeat.Stop()
+
 	for {
+		// While paused, jobs is nil so the worker parks until resumed
+		jobs, resume := p.jobs, p.resumeChan()
+		if resume != nil {
+			jobs = nil
+		}
+
 		select {
 		case <-p.shutdown:
-			log.Printf("[Worker-%d] Shutting down", id)
+			slog.Debug("Worker shutting down", slog.Int("worker_id", id))
+			p.supervisor.retire(id)
 			return
-		case job, ok := <-p.jobs:
+		case <-p.stop:
+			slog.Debug("Worker retired", slog.Int("worker_id", id))
+			p.supervisor.retire(id)
+			return
+		case <-resume:
+			continue
+		case <-heartbeat.C:
+			if !p.supervisor.beat(id, false) {
+				return
+			}
+		case job, ok := <-jobs:
 			if !ok {
+				p.supervisor.retire(id)
+				return
+			}
+			p.supervisor.beat(id, true)
+			metrics.QueueDepth.Set(float64(p.QueueDepth()))
+			ctx, cancel := p.jobContext(job)
+			p.safeProcessJob(ctx, id, job)
+			cancel()
+
+			// A replacement took over while the job ran
+			if !p.supervisor.beat(id, false) {
+				slog.Warn("⚠️  Replaced worker finished its job, exiting", slog.Int("worker_id", id))
 				return
 			}
-			p.processJob(id, job)
 		}
 	}
 }
 
-// processJob handles a single transcription job.
-func (p *Pool) processJob(workerID int, job rabbitmq.Job) {
-	request := job.Request
-	retryInfo := ""
-	if request.RetryCount > 0 {
-		retryInfo = fmt.Sprintf(" [retry %d]", request.RetryCount)
-	}
-	log.Printf("[W%d] Job #%d%s", workerID, request.AttachmentID, retryInfo)
-
-	// 1. Validate file exists
-	if !validator.FileExists(request.AudioFilePath) {
-		err := p.producer.PublishError(
-			request.AttachmentID,
-			request.ImportBatchID,
-			"Audio file not found: "+request.AudioFilePath,
-		)
-		if err != nil {
-			log.Printf("[W%d] ❌ Publish failed: %v", workerID, err)
-			job.Delivery.Nack(false, true) // Requeue
+// Pause stops workers from taking new jobs; in-flight jobs run to completion
+// and prefetched deliveries stay unacknowledged. It returns false if the pool
+// was already paused.
+func (p *Pool) Pause() bool {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+
+	if p.paused.Load() {
+		return false
+	}
+	p.resume = make(chan struct{})
+	p.pausedAt = time.Now()
+	p.paused.Store(true)
+
+	go p.warnWhilePaused(p.resume, p.pausedAt)
+	slog.Warn("⏸️  Worker pool paused")
+	return true
+}
+
+// Resume lets workers take jobs again. It returns false if the pool was not paused.
+func (p *Pool) Resume() bool {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+
+	if !p.paused.Load() {
+		return false
+	}
+	p.paused.Store(false)
+	close(p.resume)
+	p.resume = nil
+
+	slog.Info("▶️  Worker pool resumed", slog.Duration("paused_for", time.Since(p.pausedAt)))
+	return true
+}
+
+// resumeChan returns the channel closed on Resume, or nil if not paused.
+func (p *Pool) resumeChan() chan struct{} {
+	if !p.paused.Load() {
+		return nil
+	}
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	return p.resume
+}
+
+// warnWhilePaused logs a warning every pauseWarnAfter until resume is closed.
+func (p *Pool) warnWhilePaused(resume chan struct{}, since time.Time) {
+	ticker := time.NewTicker(p.pauseWarnAfter)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-resume:
+			return
+		case <-p.shutdown:
 			return
+		case <-ticker.C:
+			slog.Warn("⚠️  Worker pool still paused", slog.Duration("paused_for", time.Since(since)))
 		}
-		job.Delivery.Ack(false)
+	}
+}
+
+// safeProcessJob runs processJob, recovering from panics so the worker
+// survives. The delivery of a panicking job is requeued.
+func (p *Pool) safeProcessJob(ctx context.Context, workerID int, job rabbitmq.Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.panicCount.Add(1)
+			metrics.WorkerPanics.Inc()
+			jobLogger(workerID, job.Request).Error("💥 Job panicked",
+				slog.Any("panic", r),
+				slog.String("stack", string(debug.Stack())))
+			job.Delivery.Nack(false, true) // Requeue
+		}
+	}()
+
+	p.processJob(ctx, workerID, job)
+}
+
+// processJob handles a single transcription job within ctx, which carries
+// its trace and its deadline.
+func (p *Pool) processJob(ctx context.Context, workerID int, job rabbitmq.Job) {
+	request := job.Request
+	logger := jobLogger(workerID, request)
+
+	if job.Span != nil {
+		defer job.Span.End()
+	}
+
+	logger.Info("Job received",
+		slog.Int("retry_count", request.RetryCount),
+		slog.String("model", request.Model))
+
+	// Recorded once the job is handled; a panic leaves Status empty
+	record := &JobRecord{
+		AttachmentID: request.AttachmentID,
+		WorkerID:     workerID,
+		StartedAt:    time.Now(),
+		Model:        request.Model,
+	}
+	if request.ModelOverride != "" {
+		record.Model = request.ModelOverride
+	}
+	defer p.recordJob(record)
+
+	// 1. Drop a second copy of a job that is already being processed
+	if _, loaded := p.inflight.LoadOrStore(request.AttachmentID, struct{}{}); loaded {
+		record.Status = p.dropDuplicate(ctx, workerID, job)
 		return
 	}
+	defer p.inflight.Delete(request.AttachmentID)
 
-	// 2. Validate file extension
-	if !validator.ValidateAudioExtension(request.AudioFilePath) {
-		err := p.producer.PublishError(
-			request.AttachmentID,
-			request.ImportBatchID,
-			"Unsupported audio format",
-		)
-		if err != nil {
-			log.Printf("[W%d] ❌ Publish failed: %v", workerID, err)
-			job.Delivery.Nack(false, true)
+	p.processing.Add(1)
+	defer p.processing.Add(-1)
+	metrics.WorkersBusy.Inc()
+	defer metrics.WorkersBusy.Dec()
+
+	// Queue wait covers the broker queue when the publisher stamped the
+	// message, otherwise only the time buffered in the pool
+	validationStart := time.Now()
+	var queueWait time.Duration
+	switch {
+	case !job.EnqueuedAt.IsZero():
+		queueWait = max(validationStart.Sub(job.EnqueuedAt), 0) // clocks may be skewed
+		metrics.QueueWait.Observe(queueWait.Seconds())
+	case !job.ReceivedAt.IsZero():
+		queueWait = validationStart.Sub(job.ReceivedAt)
+	}
+	record.QueueWaitMs = queueWait.Milliseconds()
+
+	// 2. Reject a job that waited so long its requester has given up on it
+	if p.maxQueueAge > 0 && queueWait > p.maxQueueAge {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeTimeout, fmt.Sprintf("Job expired in queue after %s", queueWait.Round(time.Second)))
+		return
+	}
+
+	validateCtx, validateSpan := telemetry.Start(ctx, "job.validate")
+	defer validateSpan.End()
+
+	// 3. Validate the model override before anything reaches Python
+	if request.ModelOverride != "" && !p.models[request.ModelOverride] {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeInvalidRequest, "Model not allowed: "+request.ModelOverride)
+		return
+	}
+
+	// 4. Validate path is inside an allowed directory
+	if err := validator.ValidateFilePath(request.AudioFilePath, p.allowedDirs); err != nil {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	// 5. Validate file exists and can be read; later checks reuse the metadata
+	file, err := validator.StatFile(request.AudioFilePath)
+	if err != nil {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeFileNotFound, "Audio file not found: "+request.AudioFilePath)
+		return
+	}
+	if !file.IsReadable {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeFileNotFound, "Audio file not readable: "+request.AudioFilePath)
+		return
+	}
+
+	// 6. Validate file extension
+	if !p.validator.ValidateExtension(request.AudioFilePath) {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeUnsupportedFormat, "Unsupported audio format")
+		return
+	}
+
+	// 7. Validate content type from magic bytes
+	mimeType, err := p.validator.ValidateMIMEType(request.AudioFilePath)
+	if err != nil {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeUnsupportedFormat, err.Error())
+		return
+	}
+	if expected := validator.ExpectedMIMEType(request.AudioFilePath); expected != "" && mimeType != expected {
+		logger.Warn("⚠️  Extension does not match content type",
+			slog.String("expected", expected),
+			slog.String("mime_type", mimeType))
+	}
+
+	// 8. Validate file size before occupying a Python process
+	if err := file.ValidateSize(p.maxFileMB); err != nil {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeFileTooLarge, err.Error())
+		return
+	}
+
+	// 9. Validate audio duration; if ffprobe fails, Python validates it instead
+	if err := validator.ValidateAudioDurationWithContext(validateCtx, request.AudioFilePath, p.maxDuration); err != nil {
+		var tooLong *validator.AudioTooLongError
+		if !errors.As(err, &tooLong) {
+			logger.Warn("⚠️  Duration probe failed", slog.Any("error", err))
+		} else {
+			record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeFileTooLarge, tooLong.Error())
 			return
 		}
-		job.Delivery.Ack(false)
+	}
+
+	// 10. Validate the file has a readable audio stream; probe failures are left to Python
+	audio, err := validator.ProbeAudioStreamsWithContext(validateCtx, request.AudioFilePath, validator.FfprobePath)
+	if err != nil {
+		logger.Warn("⚠️  Stream probe failed", slog.Any("error", err))
+	} else if !audio.HasAudioStream {
+		record.Status = p.reject(ctx, workerID, job, rabbitmq.ErrCodeUnsupportedFormat, (&validator.NoAudioStreamError{Path: request.AudioFilePath}).Error())
 		return
+	} else if p.sampleRate > 0 && audio.SampleRate != p.sampleRate {
+		logger.Warn("⚠️  Sample rate differs from AUDIO_SAMPLE_RATE, audio will be resampled",
+			slog.Int("sample_rate", audio.SampleRate),
+			slog.Int("expected", p.sampleRate))
 	}
 
-	// 3. Execute Python worker — start processing timer
-	start := time.Now()
-	response, err := p.processPool.Execute(request)
-	processingTimeMs := time.Since(start).Milliseconds()
+	// 11. Execute Python worker — start processing timer
+	processPool := p.selectPool(request.Model)
+	if request.ModelOverride != "" {
+		processPool = p.selectPool(request.ModelOverride)
+	}
+	validateSpan.End()
+	validationMs := time.Since(validationStart).Milliseconds()
 
-	// 4. Handle execution error
-	if err != nil {
-		p.handleFailure(workerID, job, err.Error())
+	// Bursts of deliveries are admitted at JobsPerSecond instead of all
+	// competing for a Python process at once
+	if err := p.admit(ctx); err != nil {
+		logger.Info("⏸️  Job not admitted, requeueing", slog.Any("error", err))
+		job.Delivery.Nack(false, true)
+		record.Status = JobRequeued
 		return
 	}
 
-	// 5. Handle Python error response
-	if !response.Success {
-		p.handleFailure(workerID, job, response.ErrorMessage)
+	// In a dry run the rest of the pipeline runs without Python
+	execCtx, executeSpan := telemetry.Start(ctx, "job.execute")
+	start := time.Now()
+	var response *rabbitmq.PythonWorkerResponse
+	if p.dryRun {
+		response, err = dryRunResponse(request), nil
+	} else {
+		response, err = processPool.ExecuteWithContext(execCtx, request)
+	}
+	processingTimeMs := time.Since(start).Milliseconds()
+
+	executeSpan.RecordError(err)
+	executeSpan.End()
+
+	// 12. Handle a dead or timed out process, or a Python error response.
+	// A crashed process is replaced now so the model is loaded before the retry
+	if err != nil {
+		if respawner, ok := processPool.(deadRespawner); ok && errors.Is(err, ErrProcessDead) {
+			go respawner.RespawnDead()
+		}
+		record.Status = p.handleFailure(ctx, workerID, job, err)
 		return
 	}
 
-	// 6. Success - publish result
-	err = p.producer.PublishSuccess(
+	// 13. Success - publish result
+	result := p.producer.SuccessResult(
 		request.AttachmentID,
 		request.ImportBatchID,
 		response.Texto,
 		response.Duration,
+		response.Model,
 		processingTimeMs,
+		response.IsSilent,
+		response.Segments,
 	)
+	result.QueueWaitMs = queueWait.Milliseconds()
+	result.ValidationMs = validationMs
+	result.ExecutionMs = processingTimeMs
+	result.ExpiresAt = request.ResultExpiresAt
+	result.Language = request.Language
+	stampProcessed(&result, request.SubmittedAt)
+	result.DebugInfo = response.Stderr
+
+	publishCtx, publishSpan := telemetry.Start(publishContext(ctx), "job.publish")
+	publishStart := time.Now()
+	err = p.producer.PublishResultWithContext(publishCtx, result)
+	result.PublishMs = time.Since(publishStart).Milliseconds()
+	publishSpan.RecordError(err)
+	publishSpan.End()
 	if err != nil {
-		log.Printf("[W%d] ❌ Publish failed: %v", workerID, err)
+		logger.Error("❌ Publish failed", slog.Any("error", err))
 		job.Delivery.Nack(false, true)
+		record.Status = JobRequeued
 		return
 	}
 
 	job.Delivery.Ack(false)
-	log.Printf("[W%d] ✅ #%d done (%.1fs)", workerID, request.AttachmentID, response.Duration)
+	record.Status = JobSucceeded
+	record.Model = result.Model
+	record.Duration = response.Duration
+	p.completed.Add(1)
+	p.timings.record(result)
+	metrics.JobsTotal.Inc("success")
+	metrics.JobDuration.Observe(float64(processingTimeMs)/1000, result.Model)
+	if !request.SubmittedAt.IsZero() {
+		metrics.JobLatency.Observe(float64(result.LatencyMs) / 1000)
+	}
+
+	// 14. Push the result to the client webhook, if any
+	if request.CallbackURL != "" {
+		p.sendCallback(logger, request.CallbackURL, result)
+	}
+
+	done := []any{
+		slog.String("model", result.Model),
+		slog.Float64("duration_s", response.Duration),
+		slog.Int64("processing_time_ms", processingTimeMs),
+		slog.Int64("queue_wait_ms", result.QueueWaitMs),
+		slog.Int64("publish_ms", result.PublishMs),
+	}
+	switch {
+	case p.dryRun:
+		logger.Info("🧪 Dry-run job done", done...)
+	case response.IsSilent:
+		logger.Info("🔇 Job silent", done...)
+	default:
+		logger.Info("✅ Job done", done...)
+	}
 }
 
-// handleFailure handles a failed job, either retrying or publishing error.
-func (p *Pool) handleFailure(workerID int, job rabbitmq.Job, errorMessage string) {
+// recordJob adds record to the recent jobs once processJob returns or
+// panics.
+func (p *Pool) recordJob(record *JobRecord) {
+	if record.Status == "" {
+		record.Status = JobPanicked
+	}
+	record.FinishedAt = time.Now()
+	p.recentJobs.add(record)
+}
+
+// RecentJobs returns up to n of the most recently finished jobs, newest first.
+func (p *Pool) RecentJobs(n int) []JobRecord {
+	return p.recentJobs.recent(n)
+}
+
+// ProcessStats returns the per-process stats of each process pool, keyed
+// by model. Executors that do not run processes are left out.
+func (p *Pool) ProcessStats() map[string][]ProcessStat {
+	stats := make(map[string][]ProcessStat, len(p.pools()))
+	for model, processPool := range p.pools() {
+		if statter, ok := processPool.(processStatter); ok {
+			stats[model] = statter.ProcessStats()
+		}
+	}
+	return stats
+}
+
+// jobLogger returns a logger carrying the worker and attachment of a job.
+func jobLogger(workerID int, request rabbitmq.TranscriptionRequest) *slog.Logger {
+	return slog.With(
+		slog.Int("worker_id", workerID),
+		slog.Int("attachment_id", request.AttachmentID),
+	)
+}
+
+// reject publishes a non-retryable error result for job, with code as its
+// ErrorCode, and ACKs it. If publishing fails the delivery is requeued
+// instead. It returns the JobRecord status of the outcome.
+func (p *Pool) reject(ctx context.Context, workerID int, job rabbitmq.Job, code string, errorMessage string) string {
+	logger := jobLogger(workerID, job.Request)
+	logger.Warn("⚠️  Job rejected",
+		slog.String("reason", errorMessage),
+		slog.String("error_code", code))
+
+	result := p.producer.ErrorResult(
+		job.Request.AttachmentID,
+		job.Request.ImportBatchID,
+		code,
+		errorMessage,
+	)
+	result.ExpiresAt = job.Request.ResultExpiresAt
+	result.Language = job.Request.Language
+	stampProcessed(&result, job.Request.SubmittedAt)
+	if err := p.producer.PublishResultWithContext(publishContext(ctx), result); err != nil {
+		logger.Error("❌ Publish failed", slog.Any("error", err))
+		job.Delivery.Nack(false, true) // Requeue
+		return JobRequeued
+	}
+	job.Delivery.Ack(false)
+	p.failed.Add(1)
+	metrics.JobsTotal.Inc("error")
+	return JobRejected
+}
+
+// stampProcessed sets the ProcessedAt of a result about to be published,
+// and its LatencyMs if the request carried a SubmittedAt.
+func stampProcessed(result *rabbitmq.TranscriptionResult, submittedAt time.Time) {
+	result.ProcessedAt = time.Now()
+	if !submittedAt.IsZero() {
+		result.LatencyMs = max(result.ProcessedAt.Sub(submittedAt), 0).Milliseconds() // clocks may be skewed
+	}
+}
+
+// dropDuplicate archives a job whose AttachmentID is already in flight in
+// the dead letter queue, so the attachment only produces one result.
+func (p *Pool) dropDuplicate(ctx context.Context, workerID int, job rabbitmq.Job) string {
+	logger := jobLogger(workerID, job.Request)
+	logger.Warn("♊ Duplicate job dropped, attachment already in flight")
+
+	if err := p.producer.PublishDeadWithContext(publishContext(ctx), job.Request, rabbitmq.ErrCodeDuplicate, "duplicate of an in-flight job"); err != nil {
+		logger.Error("❌ Dead letter publish failed", slog.Any("error", err))
+		job.Delivery.Nack(false, false) // No DLX on the main queue, the copy is discarded
+	} else {
+		job.Delivery.Ack(false)
+	}
+	p.duplicates.Add(1)
+	metrics.JobsTotal.Inc("duplicate")
+	return JobDuplicate
+}
+
+// admit waits until the JobsPerSecond limiter lets another execution start.
+// It returns an error if ctx is done or the pool shuts down first.
+func (p *Pool) admit(ctx context.Context) error {
+	if p.admission == nil || p.admission.Allow() {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return p.admission.Wait(ctx)
+}
+
+// jobContext returns the context of a single job: the trace context
+// extracted from its message, bounded by JobTimeout.
+func (p *Pool) jobContext(job rabbitmq.Job) (context.Context, context.CancelFunc) {
+	parent := job.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	if p.jobTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, p.jobTimeout)
+}
+
+// publishContext returns the context for publishing the outcome of a job
+// running in ctx. It keeps the trace but not the deadline: a job that timed
+// out in Python still has to publish its result or retry.
+func publishContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// selectPool returns the process pool for model, falling back to DefaultPool.
+// A process in any pool can still serve a ModelOverride by loading it on demand.
+func (p *Pool) selectPool(model string) Executor {
+	if processPool, ok := p.pools()[model]; ok && model != "" {
+		return processPool
+	}
+	return p.pools()[DefaultPool]
+}
+
+// handleFailure handles a failed job, either retrying or archiving it as
+// dead, and returns the JobRecord status of the outcome. Python errors
+// caused by the audio itself are archived without retrying.
+func (p *Pool) handleFailure(ctx context.Context, workerID int, job rabbitmq.Job, failure error) string {
 	request := job.Request
+	logger := jobLogger(workerID, request)
+	errorMessage := failure.Error()
+
+	// No process was free for this model: backpressure, not a failure of
+	// the job, so it goes back to the queue without spending a retry
+	if errors.Is(failure, ErrNoWorkers) {
+		logger.Warn("⏳ No Python process available, requeueing job", slog.String("error", errorMessage))
+		job.Delivery.Nack(false, true)
+		return JobRequeued
+	}
+
+	var pythonErr *ErrPythonError
+	retryable := !errors.As(failure, &pythonErr) || pythonErr.Retryable()
 
-	if rabbitmq.ShouldRetry(request.RetryCount) {
-		log.Printf("[W%d] 🔄 #%d retry %d/%d",
-			workerID, request.AttachmentID, request.RetryCount+1, rabbitmq.MaxRetries)
+	if retryable && rabbitmq.ShouldRetry(request.RetryCount) {
+		logger.Warn("🔄 Job retry",
+			slog.Int("attempt", request.RetryCount+1),
+			slog.Int("max_retries", rabbitmq.MaxRetries),
+			slog.String("error", errorMessage))
 
-		err := p.producer.PublishRetry(request)
+		err := p.producer.PublishRetryWithContext(publishContext(ctx), request)
 		if err != nil {
-			log.Printf("[W%d] ❌ Retry failed: %v", workerID, err)
+			logger.Error("❌ Retry failed", slog.Any("error", err))
 			job.Delivery.Nack(false, true)
-			return
+			return JobRequeued
 		}
 		job.Delivery.Ack(false)
-		return
+		metrics.JobsTotal.Inc("retry")
+		return JobRetried
 	}
 
-	// Max retries exceeded
-	log.Printf("[W%d] ❌ #%d failed: %s", workerID, request.AttachmentID, errorMessage)
+	// Max retries exceeded, or retrying cannot help
+	code := rabbitmq.ErrCodeMaxRetries
+	if !retryable {
+		code = rabbitmq.ErrCodePythonError
+	}
+	failed := []any{
+		slog.String("error", errorMessage),
+		slog.Bool("retryable", retryable),
+		slog.String("error_code", code),
+	}
+	if pythonErr != nil && pythonErr.Stderr != "" {
+		failed = append(failed, slog.String("stderr", pythonErr.Stderr))
+	}
+	logger.Error("❌ Job failed", failed...)
 
-	err := p.producer.PublishError(request.AttachmentID, request.ImportBatchID, errorMessage)
+	err := p.producer.PublishDeadWithContext(publishContext(ctx), request, code, errorMessage)
 	if err != nil {
-		log.Printf("[W%d] ❌ Error publish failed: %v", workerID, err)
+		logger.Error("❌ Dead letter publish failed", slog.Any("error", err))
 		job.Delivery.Nack(false, true) // Requeue
-		return
+		return JobRequeued
 	}
 	job.Delivery.Ack(false)
+	p.failed.Add(1)
+	metrics.JobsTotal.Inc("error")
+	return JobFailed
 }
 
-// Shutdown gracefully stops all workers.
+// Stats returns job counters and process statistics across all process pools.
+func (p *Pool) Stats() PoolStats {
+	stats := PoolStats{
+		ByModel:        make(map[string]ProcessStats, len(p.pools())),
+		JobsQueued:     p.QueueDepth(),
+		JobsProcessing: p.processing.Load(),
+		JobsCompleted:  p.completed.Load(),
+		JobsFailed:     p.failed.Load(),
+		Panics:         p.panicCount.Load(),
+		WorkerCount:    p.NumWorkers(),
+		UptimeSeconds:  time.Since(p.startedAt).Seconds(),
+		Paused:         p.paused.Load(),
+
+		DuplicatesDropped:  p.duplicates.Load(),
+		WorkerRestartCount: p.supervisor.Restarts(),
+
+		AvgQueueWaitMs:  p.timings.average(&p.timings.queueWait),
+		AvgValidationMs: p.timings.average(&p.timings.validation),
+		AvgExecutionMs:  p.timings.average(&p.timings.execution),
+		AvgPublishMs:    p.timings.average(&p.timings.publish),
+	}
+
+	for model, processPool := range p.pools() {
+		total, alive, busy := executorCounts(processPool)
+		sub := ProcessStats{Total: total, Alive: alive, Busy: busy, Idle: alive - busy}
+		stats.ByModel[model] = sub
+
+		stats.Total += sub.Total
+		stats.Alive += sub.Alive
+		stats.Busy += sub.Busy
+		stats.Idle += sub.Idle
+	}
+	return stats
+}
+
+// Shutdown gracefully stops all workers and their process pools.
 func (p *Pool) Shutdown() {
 	close(p.shutdown)
-	close(p.jobs)
+	p.submitMu.Lock()
+	p.draining = true
+	p.closeJobs.Do(func() { close(p.jobs) })
+	p.submitMu.Unlock()
 	p.wg.Wait()
+
+	for _, processPool := range p.pools() {
+		if s, ok := processPool.(shutdowner); ok {
+			s.Shutdown()
+		}
+	}
 }
@@ -0,0 +1,709 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"whisper-local/internal/config"
+	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/rabbitmq/rabbitmqtest"
+	"whisper-local/internal/validator"
+	"whisper-local/internal/worker"
+	"whisper-local/internal/worker/workertest"
+)
+
+// testTimeout bounds every wait on the pool or the mock broker.
+const testTimeout = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	// Probe failures are left to Python, so the tests do not depend on
+	// whether ffprobe is installed
+	validator.FfprobePath = filepath.Join(os.TempDir(), "whisper-test-no-ffprobe")
+	os.Exit(m.Run())
+}
+
+// ackEvent is an acknowledgement of a test delivery.
+type ackEvent struct {
+	ack     bool // Ack, otherwise Nack or Reject
+	requeue bool
+}
+
+// recordingAcknowledger reports every acknowledgement of a delivery on events.
+type recordingAcknowledger struct {
+	events chan ackEvent
+}
+
+// Ack implements amqp.Acknowledger.
+func (a *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.events <- ackEvent{ack: true}
+	return nil
+}
+
+// Nack implements amqp.Acknowledger.
+func (a *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.events <- ackEvent{requeue: requeue}
+	return nil
+}
+
+// Reject implements amqp.Acknowledger.
+func (a *recordingAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.events <- ackEvent{requeue: requeue}
+	return nil
+}
+
+// poolFixture is a started Pool whose producer publishes to a MockBroker.
+type poolFixture struct {
+	pool     *worker.Pool
+	broker   *rabbitmqtest.MockBroker
+	executor *workertest.MockExecutor
+	results  <-chan amqp.Delivery
+}
+
+// newPoolFixture starts a one-worker Pool running executor. Retries wait an
+// hour, so they stay in their retry queue for inspection.
+func newPoolFixture(t *testing.T, executor *workertest.MockExecutor) *poolFixture {
+	t.Helper()
+	f := startPool(t, executor, worker.PoolOptions{NumWorkers: 1})
+	f.executor = executor
+	return f
+}
+
+// startPool starts a Pool with opts running executor, set up like
+// newPoolFixture. A zero JobTimeout is replaced with testTimeout.
+func startPool(t *testing.T, executor worker.Executor, opts worker.PoolOptions) *poolFixture {
+	t.Helper()
+	broker := rabbitmqtest.NewMockBroker()
+	producer, err := rabbitmq.NewProducerFromBroker(broker, rabbitmq.ProducerOptions{
+		Model: "base",
+		Retry: rabbitmq.RetryPolicy{BaseDelayMs: 3600000, MaxDelayMs: 3600000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { producer.Close() })
+
+	if opts.JobTimeout == 0 {
+		opts.JobTimeout = testTimeout
+	}
+	pool := worker.NewPool(map[string]worker.Executor{worker.DefaultPool: executor}, producer, opts)
+	pool.Start()
+	return &poolFixture{
+		pool:    pool,
+		broker:  broker,
+		results: broker.Consume(rabbitmq.ResultsQueue),
+	}
+}
+
+// submit hands a job for request to the pool and returns the channel its
+// acknowledgements are reported on.
+func (f *poolFixture) submit(request rabbitmq.TranscriptionRequest) <-chan ackEvent {
+	events := make(chan ackEvent, 1)
+	f.pool.Submit(rabbitmq.Job{
+		Request:    request,
+		Delivery:   amqp.Delivery{Acknowledger: &recordingAcknowledger{events: events}},
+		ReceivedAt: time.Now(),
+	})
+	return events
+}
+
+// waitAck returns the acknowledgement of a submitted job.
+func waitAck(t *testing.T, events <-chan ackEvent) ackEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the job to be acknowledged")
+	}
+	return ackEvent{}
+}
+
+// nextMessage returns the next message of deliveries.
+func nextMessage(t *testing.T, deliveries <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+	select {
+	case msg := <-deliveries:
+		return msg
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a message")
+	}
+	return amqp.Delivery{}
+}
+
+// nextResult decodes the next published result.
+func (f *poolFixture) nextResult(t *testing.T) rabbitmq.TranscriptionResult {
+	t.Helper()
+	var result rabbitmq.TranscriptionResult
+	if err := json.Unmarshal(nextMessage(t, f.results).Body, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// writeWAV writes a short silent WAV file to a temporary directory and
+// returns its path.
+func writeWAV(t *testing.T) string {
+	t.Helper()
+	data := make([]byte, 1600) // 0.1 s of 8 kHz 16-bit mono silence
+	header := []byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00data\x00\x00\x00\x00")
+	putUint32 := func(b []byte, v int) {
+		b[0], b[1], b[2], b[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+	}
+	putUint32(header[4:8], 36+len(data))
+	putUint32(header[40:44], len(data))
+
+	path := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(path, append(header, data...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newStubProcessPool starts a ProcessPool of workers shell processes that
+// speak the worker protocol. They answer pings at once and hold every
+// transcription until the file hold exists.
+func newStubProcessPool(t *testing.T, workers int, hold string) *worker.ProcessPool {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "worker.sh")
+	body := fmt.Sprintf(`echo READY
+while read -r line; do
+	case "$line" in
+	*'"ping"'*) echo '{"pong":true}' ;;
+	*)
+		while [ ! -f %q ]; do sleep 0.01; done
+		echo '{"success":true,"texto":"stub","model":"base"}'
+		;;
+	esac
+done
+`, hold)
+	if err := os.WriteFile(script, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := worker.NewProcessPool(&config.Config{
+		MaxWorkers:         workers,
+		PythonPath:         "/bin/sh",
+		WorkerScript:       script,
+		ProcessIdleTimeout: time.Hour,
+		MaxSpawnBackoff:    time.Second,
+		SpawnReadyTimeout:  testTimeout,
+		PingEnabled:        true,
+		PingTimeoutMs:      1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Shutdown)
+	return pool
+}
+
+// enteringExecutor reports on entered each request handed to the Executor
+// it wraps, before running it.
+type enteringExecutor struct {
+	worker.Executor
+	entered chan int
+}
+
+// ExecuteWithContext implements worker.Executor.
+func (e *enteringExecutor) ExecuteWithContext(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+	e.entered <- request.AttachmentID
+	return e.Executor.ExecuteWithContext(ctx, request)
+}
+
+func TestPool_SuccessfulJob(t *testing.T) {
+	executor := &workertest.MockExecutor{
+		Response: &rabbitmq.PythonWorkerResponse{Success: true, Texto: "hola mundo", Duration: 0.1, Model: "base"},
+	}
+	f := newPoolFixture(t, executor)
+	defer f.pool.Shutdown()
+
+	path := writeWAV(t)
+	if event := waitAck(t, f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 1, AudioFilePath: path})); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+
+	result := f.nextResult(t)
+	if !result.Success || result.AttachmentID != 1 || result.Texto != "hola mundo" || result.Model != "base" {
+		t.Errorf("result = %+v", result)
+	}
+	if requests := executor.Requests(); len(requests) != 1 || requests[0].AudioFilePath != path {
+		t.Errorf("executed requests = %+v", requests)
+	}
+	if stats := f.pool.Stats(); stats.JobsCompleted != 1 || stats.JobsFailed != 0 {
+		t.Errorf("completed %d, failed %d", stats.JobsCompleted, stats.JobsFailed)
+	}
+}
+
+func TestPool_FileNotFound_PublishesError(t *testing.T) {
+	executor := &workertest.MockExecutor{}
+	f := newPoolFixture(t, executor)
+	defer f.pool.Shutdown()
+
+	path := filepath.Join(t.TempDir(), "missing.wav")
+	if event := waitAck(t, f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 2, AudioFilePath: path})); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+
+	result := f.nextResult(t)
+	if result.Success || result.AttachmentID != 2 || result.ErrorCode != rabbitmq.ErrCodeFileNotFound {
+		t.Errorf("result = %+v", result)
+	}
+	if requests := executor.Requests(); len(requests) != 0 {
+		t.Errorf("Python ran for a missing file: %+v", requests)
+	}
+}
+
+func TestPool_InvalidExtension_PublishesError(t *testing.T) {
+	executor := &workertest.MockExecutor{}
+	f := newPoolFixture(t, executor)
+	defer f.pool.Shutdown()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if event := waitAck(t, f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 3, AudioFilePath: path})); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+
+	result := f.nextResult(t)
+	if result.Success || result.AttachmentID != 3 || result.ErrorCode != rabbitmq.ErrCodeUnsupportedFormat {
+		t.Errorf("result = %+v", result)
+	}
+	if requests := executor.Requests(); len(requests) != 0 {
+		t.Errorf("Python ran for an unsupported file: %+v", requests)
+	}
+}
+
+func TestPool_PythonError_TriggersRetry(t *testing.T) {
+	executor := &workertest.MockExecutor{Err: &worker.ErrPythonError{Message: "CUDA out of memory"}}
+	f := newPoolFixture(t, executor)
+	defer f.pool.Shutdown()
+
+	request := rabbitmq.TranscriptionRequest{AttachmentID: 4, AudioFilePath: writeWAV(t)}
+	if event := waitAck(t, f.submit(request)); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+
+	msg := nextMessage(t, f.broker.Consume(rabbitmq.RetryQueueName(1)))
+	var retried rabbitmq.TranscriptionRequest
+	if err := json.Unmarshal(msg.Body, &retried); err != nil {
+		t.Fatal(err)
+	}
+	if retried.AttachmentID != 4 || retried.RetryCount != 1 {
+		t.Errorf("retried request = %+v", retried)
+	}
+	if n := f.broker.QueueLen(rabbitmq.ResultsQueue); n != 0 {
+		t.Errorf("%d results published for a retried job", n)
+	}
+}
+
+func TestPool_MaxRetriesExceeded_PublishesError(t *testing.T) {
+	executor := &workertest.MockExecutor{Err: &worker.ErrPythonError{Message: "CUDA out of memory"}}
+	f := newPoolFixture(t, executor)
+	defer f.pool.Shutdown()
+	deadLetters := f.broker.Consume(rabbitmq.DeadLetterQueue)
+
+	request := rabbitmq.TranscriptionRequest{AttachmentID: 5, AudioFilePath: writeWAV(t), RetryCount: rabbitmq.MaxRetries}
+	if event := waitAck(t, f.submit(request)); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+
+	// The last failure is archived in the dead letter queue
+	var dead rabbitmq.DeadLetterMessage
+	if err := json.Unmarshal(nextMessage(t, deadLetters).Body, &dead); err != nil {
+		t.Fatal(err)
+	}
+	if dead.Request.AttachmentID != 5 || dead.ErrorCode != rabbitmq.ErrCodeMaxRetries || dead.Error != "CUDA out of memory" {
+		t.Errorf("dead letter = %+v", dead)
+	}
+	for attempt := 1; attempt <= rabbitmq.MaxRetries; attempt++ {
+		if n := f.broker.QueueLen(rabbitmq.RetryQueueName(attempt)); n != 0 {
+			t.Errorf("%d messages in %s", n, rabbitmq.RetryQueueName(attempt))
+		}
+	}
+	if stats := f.pool.Stats(); stats.JobsFailed != 1 {
+		t.Errorf("failed %d, want 1", stats.JobsFailed)
+	}
+}
+
+func TestPool_Shutdown_WaitsForInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	executor := &workertest.MockExecutor{
+		Func: func(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+			close(started)
+			<-release
+			return &rabbitmq.PythonWorkerResponse{Success: true, Texto: "fin"}, nil
+		},
+	}
+	f := newPoolFixture(t, executor)
+
+	events := f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 6, AudioFilePath: writeWAV(t)})
+	select {
+	case <-started:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the job to start")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		f.pool.Shutdown()
+		close(done)
+	}()
+
+	// Shutdown cannot return while the job is still running
+	select {
+	case <-done:
+		t.Fatal("Shutdown returned before the in-flight job finished")
+	case event := <-events:
+		t.Fatalf("job acknowledged before it finished: %+v", event)
+	default:
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for Shutdown")
+	}
+
+	// The job was acknowledged and its result published before Shutdown returned
+	select {
+	case event := <-events:
+		if !event.ack {
+			t.Fatalf("delivery not acked: %+v", event)
+		}
+	default:
+		t.Fatal("job not acknowledged when Shutdown returned")
+	}
+	if result := f.nextResult(t); !result.Success || result.AttachmentID != 6 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestPool_PanicRecovery(t *testing.T) {
+	executor := &workertest.MockExecutor{
+		Func: func(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+			if request.AttachmentID == 7 {
+				panic("executor bug")
+			}
+			return &rabbitmq.PythonWorkerResponse{Success: true}, nil
+		},
+	}
+	f := newPoolFixture(t, executor)
+	defer f.pool.Shutdown()
+	path := writeWAV(t)
+
+	if event := waitAck(t, f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 7, AudioFilePath: path})); event.ack || !event.requeue {
+		t.Fatalf("panicked job not requeued: %+v", event)
+	}
+	if stats := f.pool.Stats(); stats.Panics != 1 {
+		t.Errorf("panics = %d, want 1", stats.Panics)
+	}
+
+	// The only worker survived and takes the next job
+	if event := waitAck(t, f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 8, AudioFilePath: path})); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+	if result := f.nextResult(t); !result.Success || result.AttachmentID != 8 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestPool_MoreJobsThanProcesses_WaitForAProcess(t *testing.T) {
+	const jobs = 3
+	hold := filepath.Join(t.TempDir(), "release")
+	executor := &enteringExecutor{Executor: newStubProcessPool(t, 1, hold), entered: make(chan int, jobs)}
+	f := startPool(t, executor, worker.PoolOptions{NumWorkers: jobs})
+	defer f.pool.Shutdown()
+	path := writeWAV(t)
+
+	events := make([]<-chan ackEvent, jobs)
+	for i := range events {
+		events[i] = f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 30 + i, AudioFilePath: path})
+	}
+
+	// Every job reaches the single process pool while its process is held
+	// by the first one
+	for i := 0; i < jobs; i++ {
+		select {
+		case <-executor.entered:
+		case <-time.After(testTimeout):
+			t.Fatalf("only %d of %d jobs reached the executor", i, jobs)
+		}
+	}
+	if err := os.WriteFile(hold, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, ch := range events {
+		if event := waitAck(t, ch); !event.ack {
+			t.Fatalf("job %d: delivery not acked: %+v", 30+i, event)
+		}
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < jobs; i++ {
+		result := f.nextResult(t)
+		if !result.Success || result.Texto != "stub" {
+			t.Errorf("result = %+v", result)
+		}
+		seen[result.AttachmentID] = true
+	}
+	if len(seen) != jobs {
+		t.Errorf("results for attachments %v, want %d distinct", seen, jobs)
+	}
+	if n := f.broker.QueueLen(rabbitmq.RetryQueueName(1)); n != 0 {
+		t.Errorf("%d jobs retried while waiting for the busy process", n)
+	}
+}
+
+func TestPool_Shutdown_UnblocksSubmit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	executor := &workertest.MockExecutor{
+		Func: func(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+			close(started)
+			<-release
+			return &rabbitmq.PythonWorkerResponse{Success: true}, nil
+		},
+	}
+	f := newPoolFixture(t, executor)
+	path := writeWAV(t)
+
+	// The only worker holds the first job and the buffer of two fills up
+	running := f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 40, AudioFilePath: path})
+	select {
+	case <-started:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the job to start")
+	}
+	f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 41, AudioFilePath: path})
+	f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 42, AudioFilePath: path})
+
+	submitted := make(chan (<-chan ackEvent), 1)
+	go func() {
+		submitted <- f.submit(rabbitmq.TranscriptionRequest{AttachmentID: 43, AudioFilePath: path})
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("Submit returned although the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done := make(chan struct{})
+	go func() {
+		f.pool.Shutdown()
+		close(done)
+	}()
+
+	// Shutdown makes the blocked Submit requeue its job
+	select {
+	case events := <-submitted:
+		if event := waitAck(t, events); event.ack || !event.requeue {
+			t.Fatalf("blocked job not requeued: %+v", event)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("Submit still blocked after Shutdown")
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for Shutdown")
+	}
+	if event := waitAck(t, running); !event.ack {
+		t.Fatalf("in-flight job not acked: %+v", event)
+	}
+}
+
+func TestPool_ConcurrentDuplicate_ExecutesOnce(t *testing.T) {
+	release := make(chan struct{})
+	executor := &workertest.MockExecutor{
+		Func: func(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+			<-release
+			return &rabbitmq.PythonWorkerResponse{Success: true, Texto: "una vez"}, nil
+		},
+	}
+	f := startPool(t, executor, worker.PoolOptions{NumWorkers: 2})
+	f.executor = executor
+	defer f.pool.Shutdown()
+	deadLetters := f.broker.Consume(rabbitmq.DeadLetterQueue)
+
+	// Both workers take a copy of the same attachment while the first
+	// copy to get there is still running
+	request := rabbitmq.TranscriptionRequest{AttachmentID: 50, AudioFilePath: writeWAV(t)}
+	first, second := f.submit(request), f.submit(request)
+
+	// The duplicate is dropped without waiting for the running copy
+	var running <-chan ackEvent
+	var dropped ackEvent
+	select {
+	case dropped = <-first:
+		running = second
+	case dropped = <-second:
+		running = first
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the duplicate to be dropped")
+	}
+	if !dropped.ack {
+		t.Fatalf("duplicate not acked: %+v", dropped)
+	}
+	var dead rabbitmq.DeadLetterMessage
+	if err := json.Unmarshal(nextMessage(t, deadLetters).Body, &dead); err != nil {
+		t.Fatal(err)
+	}
+	if dead.Request.AttachmentID != 50 || dead.ErrorCode != rabbitmq.ErrCodeDuplicate {
+		t.Errorf("dead letter = %+v", dead)
+	}
+
+	close(release)
+	if event := waitAck(t, running); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+	if result := f.nextResult(t); !result.Success || result.AttachmentID != 50 {
+		t.Errorf("result = %+v", result)
+	}
+	if requests := executor.Requests(); len(requests) != 1 {
+		t.Errorf("executed %d times, want once", len(requests))
+	}
+	if stats := f.pool.Stats(); stats.DuplicatesDropped != 1 {
+		t.Errorf("duplicates dropped = %d, want 1", stats.DuplicatesDropped)
+	}
+}
+
+func TestPool_DryRun_SkipsExecutor(t *testing.T) {
+	executor := &workertest.MockExecutor{Err: &worker.ErrPythonError{Message: "must not run"}}
+	f := startPool(t, executor, worker.PoolOptions{NumWorkers: 1, DryRun: true})
+	defer f.pool.Shutdown()
+
+	request := rabbitmq.TranscriptionRequest{AttachmentID: 60, AudioFilePath: writeWAV(t)}
+	if event := waitAck(t, f.submit(request)); !event.ack {
+		t.Fatalf("delivery not acked: %+v", event)
+	}
+
+	result := f.nextResult(t)
+	if !result.Success || result.AttachmentID != 60 || result.Texto != worker.DryRunText {
+		t.Errorf("result = %+v", result)
+	}
+	if requests := executor.Requests(); len(requests) != 0 {
+		t.Errorf("executor ran in dry-run mode: %+v", requests)
+	}
+}
+
+func TestPool_ExecutorOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		executor   *workertest.MockExecutor
+		jobTimeout time.Duration
+		retryCount int
+
+		wantAck     bool   // Ack, otherwise Nack with requeue
+		wantResult  bool   // a successful result is published
+		wantRetry   bool   // the job goes to the first retry queue
+		wantDeadErr string // error code of the dead letter, empty for none
+	}{
+		{
+			name:       "success",
+			executor:   &workertest.MockExecutor{Response: &rabbitmq.PythonWorkerResponse{Success: true, Texto: "hola"}},
+			wantAck:    true,
+			wantResult: true,
+		},
+		{
+			name:       "timeout",
+			executor:   &workertest.MockExecutor{Delay: time.Minute},
+			jobTimeout: 100 * time.Millisecond,
+			wantAck:    true,
+			wantRetry:  true,
+		},
+		{
+			name:      "process died",
+			executor:  &workertest.MockExecutor{Err: worker.ErrProcessDead},
+			wantAck:   true,
+			wantRetry: true,
+		},
+		{
+			name:      "retryable Python error",
+			executor:  &workertest.MockExecutor{Err: &worker.ErrPythonError{Message: "CUDA out of memory"}},
+			wantAck:   true,
+			wantRetry: true,
+		},
+		{
+			name:        "permanent Python error",
+			executor:    &workertest.MockExecutor{Err: &worker.ErrPythonError{Message: "Validation error: empty audio"}},
+			wantAck:     true,
+			wantDeadErr: rabbitmq.ErrCodePythonError,
+		},
+		{
+			name:        "max retries exceeded",
+			executor:    &workertest.MockExecutor{Err: worker.ErrProcessDead},
+			retryCount:  rabbitmq.MaxRetries,
+			wantAck:     true,
+			wantDeadErr: rabbitmq.ErrCodeMaxRetries,
+		},
+		{
+			name:     "no process available",
+			executor: &workertest.MockExecutor{Err: fmt.Errorf("failed to acquire process: %w", worker.ErrNoWorkers)},
+			wantAck:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := startPool(t, tt.executor, worker.PoolOptions{NumWorkers: 1, JobTimeout: tt.jobTimeout})
+			defer f.pool.Shutdown()
+			deadLetters := f.broker.Consume(rabbitmq.DeadLetterQueue)
+
+			request := rabbitmq.TranscriptionRequest{AttachmentID: 70, AudioFilePath: writeWAV(t), RetryCount: tt.retryCount}
+			event := waitAck(t, f.submit(request))
+			if event.ack != tt.wantAck || (!event.ack && !event.requeue) {
+				t.Fatalf("acknowledgement = %+v, want ack %t", event, tt.wantAck)
+			}
+			if requests := tt.executor.Requests(); len(requests) != 1 {
+				t.Errorf("executed %d times, want once", len(requests))
+			}
+
+			if tt.wantResult {
+				if result := f.nextResult(t); !result.Success || result.AttachmentID != 70 {
+					t.Errorf("result = %+v", result)
+				}
+			}
+
+			retries := f.broker.QueueLen(rabbitmq.RetryQueueName(1))
+			if tt.wantRetry {
+				var retried rabbitmq.TranscriptionRequest
+				if err := json.Unmarshal(nextMessage(t, f.broker.Consume(rabbitmq.RetryQueueName(1))).Body, &retried); err != nil {
+					t.Fatal(err)
+				}
+				if retried.AttachmentID != 70 || retried.RetryCount != tt.retryCount+1 {
+					t.Errorf("retried request = %+v", retried)
+				}
+			} else if retries != 0 {
+				t.Errorf("%d messages in %s", retries, rabbitmq.RetryQueueName(1))
+			}
+
+			if tt.wantDeadErr != "" {
+				var dead rabbitmq.DeadLetterMessage
+				if err := json.Unmarshal(nextMessage(t, deadLetters).Body, &dead); err != nil {
+					t.Fatal(err)
+				}
+				if dead.Request.AttachmentID != 70 || dead.ErrorCode != tt.wantDeadErr {
+					t.Errorf("dead letter = %+v", dead)
+				}
+			} else {
+				select {
+				case msg := <-deadLetters:
+					t.Errorf("unexpected dead letter: %s", msg.Body)
+				default:
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package worker
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a no-op: process groups are not available.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills the process of cmd. Only SIGKILL can be delivered
+// on this platform, so every signal kills it.
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// monitorInterval is how often process resource usage is sampled.
+	monitorInterval = 10 * time.Second
+	// clockTicks is the kernel USER_HZ used by /proc/<pid>/stat, 100 on
+	// every mainstream Linux architecture.
+	clockTicks = 100
+)
+
+// RSSBytes returns the resident memory of the process at the last sample.
+func (proc *PythonProcess) RSSBytes() int64 {
+	return proc.rssBytes.Load()
+}
+
+// CPUPercent returns the CPU usage of the process over the last sample
+// interval, where 100 is one fully used core.
+func (proc *PythonProcess) CPUPercent() float64 {
+	return math.Float64frombits(proc.cpuPercent.Load())
+}
+
+// monitorProcess samples the resource usage of proc every monitorInterval
+// until it dies, killing it if its RSS exceeds the pool memory limit.
+func (p *ProcessPool) monitorProcess(proc *PythonProcess) {
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	pid := proc.cmd.Process.Pid
+	lastJiffies := int64(-1)
+	lastSample := time.Now()
+
+	for {
+		select {
+		case <-p.shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		proc.mu.Lock()
+		alive := proc.alive
+		proc.mu.Unlock()
+		if !alive {
+			return
+		}
+
+		usage, err := sampleUsage(pid)
+		if err != nil {
+			slog.Debug("Process usage sample failed", slog.Int("process_id", proc.id), slog.Any("error", err))
+			continue
+		}
+
+		now := time.Now()
+		cpu := usage.cpuPercent
+		if usage.jiffies >= 0 {
+			if lastJiffies >= 0 {
+				cpu = float64(usage.jiffies-lastJiffies) / clockTicks / now.Sub(lastSample).Seconds() * 100
+			}
+			lastJiffies = usage.jiffies
+		}
+		lastSample = now
+
+		proc.rssBytes.Store(usage.rssBytes)
+		proc.cpuPercent.Store(math.Float64bits(cpu))
+
+		if p.memoryLimit > 0 && usage.rssBytes > p.memoryLimit {
+			slog.Warn("🐘 Killing Python process over memory limit",
+				slog.Int("process_id", proc.id),
+				slog.Int64("rss_bytes", usage.rssBytes),
+				slog.Int64("limit_bytes", p.memoryLimit))
+			proc.kill()
+			p.markDead(proc)
+			return
+		}
+	}
+}
+
+// processUsage is a single resource usage sample. jiffies is -1 when the
+// platform reports a CPU percentage directly instead.
+type processUsage struct {
+	rssBytes   int64
+	jiffies    int64
+	cpuPercent float64
+}
+
+// sampleUsage reads the resource usage of pid from /proc on Linux and from
+// ps elsewhere.
+func sampleUsage(pid int) (processUsage, error) {
+	if runtime.GOOS == "linux" {
+		return sampleProcFS(pid)
+	}
+	return samplePS(pid)
+}
+
+// sampleProcFS reads CPU jiffies from /proc/<pid>/stat and VmRSS from
+// /proc/<pid>/status.
+func sampleProcFS(pid int) (processUsage, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return processUsage{}, fmt.Errorf("failed to read stat: %w", err)
+	}
+
+	// The command name may contain spaces; fields resume after its ')'
+	// with field 3 (state), so utime and stime are at indexes 11 and 12
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	if len(fields) < 13 {
+		return processUsage{}, fmt.Errorf("unexpected stat format")
+	}
+	utime, err1 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return processUsage{}, fmt.Errorf("unexpected stat format")
+	}
+
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return processUsage{}, fmt.Errorf("failed to read status: %w", err)
+	}
+
+	var rssKB int64
+	for _, line := range strings.Split(string(status), "\n") {
+		if value, ok := strings.CutPrefix(line, "VmRSS:"); ok {
+			rssKB, _ = strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			break
+		}
+	}
+
+	return processUsage{rssBytes: rssKB * 1024, jiffies: utime + stime}, nil
+}
+
+// samplePS runs ps to read RSS (in KB) and CPU percentage.
+func samplePS(pid int) (processUsage, error) {
+	out, err := exec.Command("ps", "-o", "rss=,%cpu=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return processUsage{}, fmt.Errorf("failed to run ps: %w", err)
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return processUsage{}, fmt.Errorf("unexpected ps output %q", out)
+	}
+	rssKB, err1 := strconv.ParseInt(fields[0], 10, 64)
+	cpu, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil {
+		return processUsage{}, fmt.Errorf("unexpected ps output %q", out)
+	}
+
+	return processUsage{rssBytes: rssKB * 1024, jiffies: -1, cpuPercent: cpu}, nil
+}
+
+// ProcessStat describes one process of a ProcessPool, as returned by
+// GET /admin/processes. A high ErrorRate on a single process points at
+// its GPU or a corrupted model file rather than at the jobs.
+type ProcessStat struct {
+	ID         int       `json:"id"`
+	PID        int       `json:"pid"`
+	Alive      bool      `json:"alive"`
+	Busy       bool      `json:"busy"`
+	JobCount   int64     `json:"job_count"`
+	ErrorCount int64     `json:"error_count"`
+	ErrorRate  float64   `json:"error_rate"`
+	LastUsed   time.Time `json:"last_used"`
+	Spare      bool      `json:"spare,omitempty"`
+}
+
+// ProcessStats returns the stats of each process, indexed by slot and
+// followed by the spares. Counts start over when a slot is respawned.
+func (p *ProcessPool) ProcessStats() []ProcessStat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]ProcessStat, len(p.processes)+len(p.spares))
+	for i, proc := range append(p.processes[:len(p.processes):len(p.processes)], p.spares...) {
+		proc.mu.Lock()
+		stat := ProcessStat{
+			ID:         proc.id,
+			Alive:      proc.alive,
+			Busy:       proc.busy,
+			JobCount:   proc.jobCount.Load(),
+			ErrorCount: proc.errorCount.Load(),
+			LastUsed:   proc.lastUsed,
+			Spare:      proc.spare,
+		}
+		proc.mu.Unlock()
+
+		if proc.cmd.Process != nil {
+			stat.PID = proc.cmd.Process.Pid
+		}
+		if stat.JobCount > 0 {
+			stat.ErrorRate = float64(stat.ErrorCount) / float64(stat.JobCount)
+		}
+		stats[i] = stat
+	}
+	return stats
+}
@@ -3,20 +3,38 @@ package worker
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"whisper-local/internal/config"
+	"whisper-local/internal/metrics"
 	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/telemetry"
 )
 
+// initialSpawnBackoff is the wait after the first failed respawn of a slot.
+const initialSpawnBackoff = time.Second
+
+// shutdownGracePeriod is how long Shutdown and HotSwapModel wait after
+// SIGTERM before killing a process group.
+const shutdownGracePeriod = 5 * time.Second
+
+// pingRequest is the sentinel line answered by the worker with {"pong": true}.
+const pingRequest = `{"ping":true}`
+
 // PythonProcess represents a persistent Python worker process.
 type PythonProcess struct {
 	id       int
@@ -27,57 +45,156 @@ type PythonProcess struct {
 	mu       sync.Mutex
 	busy     bool
 	alive    bool
+	retired  bool // removed by Resize, killed when released
+	spare    bool // kept in reserve until promoted to a dead slot
 	lastUsed time.Time
+	device   string // WHISPER_DEVICE the process was started with
+
+	startupDurationMs int64 // from cmd.Start to the READY line
+
+	pingTimeout time.Duration
+
+	// Resource usage sampled by monitorProcess
+	rssBytes   atomic.Int64
+	cpuPercent atomic.Uint64 // math.Float64bits
+
+	// Jobs executed by this process and how many of them failed
+	jobCount   atomic.Int64
+	errorCount atomic.Int64
+
+	// Stderr of the running request, nil while not capturing
+	stderrMu  sync.Mutex
+	stderrBuf *strings.Builder
+
+	// Respawn backoff of a dead slot, reset by a successful spawn
+	backoff       time.Duration
+	spawnAttempts int
+	nextSpawnAt   time.Time
 }
 
 // ProcessPool manages a pool of Python worker processes.
 type ProcessPool struct {
-	processes   []*PythonProcess
-	maxWorkers  int
-	idleTimeout time.Duration
-	pythonPath  string
-	workerScript string
-	pythonEnv   []string
-	mu          sync.Mutex
-	shutdown    chan struct{}
-	wg          sync.WaitGroup
+	processes     []*PythonProcess
+	maxWorkers    int
+	spares        []*PythonProcess // ready processes that replace dead ones
+	spareCount    int
+	sparesQueued  int // spares being spawned
+	nextSpareID   int
+	idleTimeout   time.Duration
+	maxBackoff    time.Duration // upper bound for respawn backoff
+	readyTimeout  time.Duration // wait for READY before a spawn is given up
+	pingTimeout   time.Duration // zero disables pings
+	memoryLimit   int64         // RSS in bytes above which a process is killed; zero disables
+	idleGrace     time.Duration // wait after SIGTERM before an idle process is killed
+	pythonPath    string
+	workerScript  string
+	workDir       string
+	pythonEnv     []string
+	gpuDevices    []string      // assigned round-robin by process id; empty uses pythonEnv
+	spawnSlots    chan struct{} // bounds spawnProcess calls running at once
+	captureStderr bool          // attach each request's stderr to its response
+	local         LocalExecutor // fallback when no process can be acquired; nil disables it
+	mu            sync.Mutex
+	resizeMu      sync.Mutex // serializes Resize calls
+	idleMu        sync.Mutex
+	idle          *sync.Cond // broadcast whenever a process stops being busy
+	releases      uint64     // notifyIdle calls, guarded by idleMu
+	shutdown      chan struct{}
+	wg            sync.WaitGroup
+
+	gracefulTerms atomic.Int64 // processes that exited after SIGTERM
+	forceKills    atomic.Int64 // processes killed after the grace period
+
+	localFallbacks atomic.Int64 // jobs handed to the LocalExecutor
 }
 
 // NewProcessPool creates a new pool of Python worker processes.
 func NewProcessPool(cfg *config.Config) (*ProcessPool, error) {
 	pool := &ProcessPool{
-		maxWorkers:   cfg.MaxWorkers,
-		idleTimeout:  cfg.ProcessIdleTimeout,
-		pythonPath:   cfg.PythonPath,
-		workerScript: cfg.WorkerScript,
-		pythonEnv:    cfg.GetPythonEnv(),
-		shutdown:     make(chan struct{}),
+		maxWorkers:    cfg.MaxWorkers,
+		spareCount:    cfg.SpareProcesses,
+		nextSpareID:   cfg.MaxWorkers + cfg.SpareProcesses,
+		idleTimeout:   cfg.ProcessIdleTimeout,
+		maxBackoff:    cfg.MaxSpawnBackoff,
+		readyTimeout:  cfg.SpawnReadyTimeout,
+		memoryLimit:   int64(cfg.MemoryLimitMB) * 1024 * 1024,
+		idleGrace:     cfg.IdleShutdownGracePeriod,
+		pythonPath:    cfg.PythonPath,
+		workerScript:  cfg.WorkerScript,
+		workDir:       cfg.WorkDir(),
+		pythonEnv:     cfg.GetPythonEnv(),
+		gpuDevices:    cfg.GPUDevices,
+		spawnSlots:    make(chan struct{}, spawnConcurrency(cfg)),
+		captureStderr: cfg.DebugResponses,
+		shutdown:      make(chan struct{}),
+	}
+	pool.idle = sync.NewCond(&pool.idleMu)
+	if cfg.PingEnabled {
+		pool.pingTimeout = time.Duration(cfg.PingTimeoutMs) * time.Millisecond
 	}
 
-	// Spawn initial processes
-	for i := 0; i < pool.maxWorkers; i++ {
-		proc, err := pool.spawnProcess(i)
-		if err != nil {
+	// Spawn initial processes and spares, at most cap(spawnSlots) loading a
+	// model at once
+	procs := make([]*PythonProcess, pool.maxWorkers+pool.spareCount)
+	errs := make([]error, len(procs))
+	var spawned sync.WaitGroup
+	for i := range procs {
+		spawned.Add(1)
+		go func(i int) {
+			defer spawned.Done()
+			procs[i], errs[i] = pool.spawnProcess(i, pool.pythonEnv)
+		}(i)
+	}
+	spawned.Wait()
+
+	for i, proc := range procs {
+		if proc != nil && i >= pool.maxWorkers {
+			proc.spare = true
+			pool.spares = append(pool.spares, proc)
+		} else if proc != nil {
+			pool.processes = append(pool.processes, proc)
+		} else if errs[i] != nil {
 			// Cleanup already spawned processes
 			pool.Shutdown()
-			return nil, fmt.Errorf("failed to spawn process %d: %w", i, err)
+			return nil, fmt.Errorf("failed to spawn process %d: %w", i, errs[i])
 		}
-		pool.processes = append(pool.processes, proc)
 	}
 
 	// Start idle cleanup goroutine
 	go pool.idleCleanupLoop()
 
-	log.Printf("🐍 %d Python workers loaded", pool.maxWorkers)
+	slog.Info("🐍 Python workers loaded",
+		slog.Int("workers", pool.maxWorkers),
+		slog.Int("spares", pool.spareCount))
 	return pool, nil
 }
 
-// spawnProcess creates and starts a new Python worker process.
-func (p *ProcessPool) spawnProcess(id int) (*PythonProcess, error) {
+// spawnConcurrency returns how many processes may be starting at once:
+// MAX_CONCURRENT_SPAWNS, or every worker and spare of the pool when it is
+// not set.
+func spawnConcurrency(cfg *config.Config) int {
+	if cfg.MaxConcurrentSpawns > 0 {
+		return cfg.MaxConcurrentSpawns
+	}
+	return max(cfg.MaxWorkers+cfg.SpareProcesses, 1)
+}
+
+// spawnProcess creates and starts a new Python worker process with the given
+// env. It waits for a free spawn slot first.
+func (p *ProcessPool) spawnProcess(id int, env []string) (*PythonProcess, error) {
+	p.spawnSlots <- struct{}{}
+	defer func() { <-p.spawnSlots }()
+
 	cmd := exec.Command(p.pythonPath, p.workerScript)
-	
+	cmd.Dir = p.workDir // Python resolves local module imports from here
+	setProcessGroup(cmd)
+
 	// Set environment variables for Python
-	cmd.Env = append(os.Environ(), p.pythonEnv...)
+	device := p.deviceFor(id, env)
+	if len(p.gpuDevices) > 0 {
+		env = setEnvValue(env, "WHISPER_DEVICE", device)
+	}
+	cmd.Env = append(os.Environ(), env...)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -97,6 +214,7 @@ func (p *ProcessPool) spawnProcess(id int) (*PythonProcess, error) {
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start process: %w", err)
 	}
+	startedAt := time.Now()
 
 	proc := &PythonProcess{
 		id:       id,
@@ -106,27 +224,152 @@ func (p *ProcessPool) spawnProcess(id int) (*PythonProcess, error) {
 		stderr:   stderr,
 		alive:    true,
 		lastUsed: time.Now(),
+		device:   device,
+
+		pingTimeout: p.pingTimeout,
 	}
 
-	// Start stderr logger
+	// Start stderr logger and resource monitor
 	go p.logStderr(proc)
+	go p.monitorProcess(proc)
 
-	// Wait for "READY" signal from Python
-	readyLine, err := proc.stdout.ReadString('\n')
-	if err != nil {
-		cmd.Process.Kill()
-		return nil, fmt.Errorf("failed to read ready signal: %w", err)
+	// Wait for "READY" signal from Python. A process stuck loading the model
+	// is killed and reaped, which also ends the read and its monitor
+	type readResult struct {
+		line string
+		err  error
+	}
+	ready := make(chan readResult, 1)
+	go func() {
+		line, err := proc.stdout.ReadString('\n')
+		ready <- readResult{line, err}
+	}()
+
+	var readyLine string
+	select {
+	case r := <-ready:
+		if r.err != nil {
+			stopProcess(proc)
+			return nil, fmt.Errorf("failed to read ready signal: %w", r.err)
+		}
+		readyLine = r.line
+	case <-time.After(p.readyTimeout):
+		stopProcess(proc)
+		return nil, fmt.Errorf("process %d did not send READY within %s", id, p.readyTimeout)
 	}
 
 	if strings.TrimSpace(readyLine) != "READY" {
-		cmd.Process.Kill()
+		stopProcess(proc)
 		return nil, fmt.Errorf("unexpected ready signal: %s", readyLine)
 	}
 
+	// Mostly model loading; a growing value points at slow disks or a
+	// saturated GPU
+	startup := time.Since(startedAt)
+	proc.startupDurationMs = startup.Milliseconds()
+	metrics.ProcessStartup.Observe(startup.Seconds())
+	slog.Info("🐍 Python process ready",
+		slog.Int("id", id),
+		slog.String("device", device),
+		slog.Duration("startup_duration", startup))
+
 	return proc, nil
 }
 
-// logStderr reads and logs stderr from a Python process.
+// deviceFor returns the inference device of process id: the GPU picked
+// round-robin from gpuDevices, or the WHISPER_DEVICE of env when none are set.
+func (p *ProcessPool) deviceFor(id int, env []string) string {
+	if len(p.gpuDevices) > 0 {
+		return p.gpuDevices[id%len(p.gpuDevices)]
+	}
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "WHISPER_DEVICE="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// Ping checks that the process still answers on its pipes. A process that
+// fails to reply within its ping timeout is killed, since a late reply would
+// be read as the response to the next request.
+func (proc *PythonProcess) Ping() error {
+	done := make(chan error, 1)
+	go func() {
+		if _, err := fmt.Fprintf(proc.stdin, "%s\n", pingRequest); err != nil {
+			done <- fmt.Errorf("failed to write ping: %w", err)
+			return
+		}
+
+		line, err := proc.stdout.ReadString('\n')
+		if err != nil {
+			done <- fmt.Errorf("failed to read pong: %w", err)
+			return
+		}
+
+		var reply struct {
+			Pong bool `json:"pong"`
+		}
+		if err := json.Unmarshal([]byte(line), &reply); err != nil || !reply.Pong {
+			done <- fmt.Errorf("unexpected ping reply: %s", strings.TrimSpace(line))
+			return
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			proc.kill()
+		}
+		return err
+	case <-time.After(proc.pingTimeout):
+		proc.kill()
+		return fmt.Errorf("no ping reply within %s", proc.pingTimeout)
+	}
+}
+
+// maxCapturedStderr bounds the stderr kept for a single request.
+const maxCapturedStderr = 64 << 10
+
+// startCapture starts buffering the stderr lines of proc.
+func (proc *PythonProcess) startCapture() {
+	proc.stderrMu.Lock()
+	defer proc.stderrMu.Unlock()
+	proc.stderrBuf = &strings.Builder{}
+}
+
+// stopCapture stops buffering and returns the captured stderr. Lines the
+// process writes right before its response may still be in the pipe and
+// end up only in the log.
+func (proc *PythonProcess) stopCapture() string {
+	proc.stderrMu.Lock()
+	defer proc.stderrMu.Unlock()
+
+	if proc.stderrBuf == nil {
+		return ""
+	}
+	captured := proc.stderrBuf.String()
+	proc.stderrBuf = nil
+	return captured
+}
+
+// capture appends a stderr line to the running capture, if any.
+func (proc *PythonProcess) capture(line string) {
+	proc.stderrMu.Lock()
+	defer proc.stderrMu.Unlock()
+
+	if proc.stderrBuf == nil || proc.stderrBuf.Len()+len(line) >= maxCapturedStderr {
+		return
+	}
+	proc.stderrBuf.WriteString(line)
+	proc.stderrBuf.WriteByte('\n')
+}
+
+// logStderr reads and logs stderr from a Python process. JSON lines such
+// as {"level":"ERROR","msg":"CUDA OOM","fields":{...}} are logged at their
+// level with their fields; any other line is logged as is at info level.
+// Lines written during a captured request are also kept for its response.
 func (p *ProcessPool) logStderr(proc *PythonProcess) {
 	reader := bufio.NewReader(proc.stderr)
 	for {
@@ -134,22 +377,115 @@ func (p *ProcessPool) logStderr(proc *PythonProcess) {
 		if err != nil {
 			return
 		}
-		log.Printf("[Py%d] %s", proc.id, strings.TrimSpace(line))
+		line = strings.TrimSpace(line)
+		proc.capture(line)
+
+		level, msg, attrs, ok := parsePythonLog(line)
+		if !ok {
+			slog.Info(line, slog.Int("process_id", proc.id))
+			continue
+		}
+		slog.Log(context.Background(), level, msg, append(attrs, slog.Int("process_id", proc.id))...)
 	}
 }
 
+// parsePythonLog parses a structured Python log line. It returns false for
+// lines that are not a JSON object with a level and a message.
+func parsePythonLog(line string) (slog.Level, string, []any, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return 0, "", nil, false
+	}
+
+	var entry struct {
+		Level  string         `json:"level"`
+		Msg    string         `json:"msg"`
+		Fields map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Level == "" || entry.Msg == "" {
+		return 0, "", nil, false
+	}
+
+	var level slog.Level
+	switch strings.ToUpper(entry.Level) {
+	case "DEBUG":
+		level = slog.LevelDebug
+	case "INFO":
+		level = slog.LevelInfo
+	case "WARNING", "WARN":
+		level = slog.LevelWarn
+	case "ERROR", "CRITICAL", "FATAL":
+		level = slog.LevelError
+	default:
+		return 0, "", nil, false
+	}
+
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, 0, len(keys)+1)
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, entry.Fields[key]))
+	}
+	return level, entry.Msg, attrs, true
+}
+
 // Execute sends a request to an available worker and returns the response.
+// A failure of the process is returned as ErrProcessDead, and a response with
+// success false as an *ErrPythonError.
 func (p *ProcessPool) Execute(request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
-	proc, err := p.acquireProcess()
+	return p.ExecuteWithContext(context.Background(), request)
+}
+
+// ExecuteWithContext is like Execute but gives up when ctx is done.
+// On cancellation the Python process is killed, since it may be stuck
+// mid-transcription, and it is respawned on the next acquire. An expired
+// deadline is returned as ErrProcessTimeout.
+//
+// When every process is busy or in spawn backoff the request goes to the
+// LocalExecutor if there is one; otherwise it waits for a process, and
+// ErrNoWorkers is only returned once ctx is done or the pool shuts down.
+func (p *ProcessPool) ExecuteWithContext(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+	traceparent := telemetry.Traceparent(ctx)
+	proc, err := p.acquireProcess(traceparent)
+	if errors.Is(err, ErrNoWorkers) {
+		if local := p.localExecutor(); local != nil {
+			return p.executeLocal(local, request, err)
+		}
+		proc, err = p.awaitProcess(ctx, traceparent)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to acquire process: %w", err)
 	}
 	defer p.releaseProcess(proc)
 
+	proc.jobCount.Add(1)
+	if p.captureStderr {
+		proc.startCapture()
+	}
+	response, err := p.roundTrip(ctx, proc, traceparent, request)
+	stderr := proc.stopCapture()
+	if err != nil {
+		proc.errorCount.Add(1)
+		var pythonErr *ErrPythonError
+		if errors.As(err, &pythonErr) {
+			pythonErr.Stderr = stderr
+		}
+		return nil, err
+	}
+	response.Stderr = stderr
+	return response, nil
+}
+
+// roundTrip sends request to the acquired proc and waits for its response.
+func (p *ProcessPool) roundTrip(ctx context.Context, proc *PythonProcess, traceparent string, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
 	// Build Python request
 	pyRequest := rabbitmq.PythonWorkerRequest{
 		AudioFilePath: request.AudioFilePath,
 		Language:      request.Language,
+		Model:         request.ModelOverride,
+		TraceContext:  traceparent,
 	}
 
 	// Send request JSON + newline
@@ -158,18 +494,43 @@ func (p *ProcessPool) Execute(request rabbitmq.TranscriptionRequest) (*rabbitmq.
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	_, err = fmt.Fprintf(proc.stdin, "%s\n", requestJSON)
-	if err != nil {
-		// Process may be dead, mark for respawn
-		proc.alive = false
-		return nil, fmt.Errorf("failed to write to process: %w", err)
+	type roundTrip struct {
+		line string
+		err  error
 	}
+	done := make(chan roundTrip, 1)
 
-	// Read response line
-	responseLine, err := proc.stdout.ReadString('\n')
-	if err != nil {
-		proc.alive = false
-		return nil, fmt.Errorf("failed to read from process: %w", err)
+	go func() {
+		if _, err := fmt.Fprintf(proc.stdin, "%s\n", requestJSON); err != nil {
+			done <- roundTrip{err: fmt.Errorf("%w: failed to write to process: %w", ErrProcessDead, err)}
+			return
+		}
+
+		// Read response line
+		line, err := proc.stdout.ReadString('\n')
+		if err != nil {
+			err = fmt.Errorf("%w: failed to read from process: %w", ErrProcessDead, err)
+		}
+		done <- roundTrip{line: line, err: err}
+	}()
+
+	var responseLine string
+	select {
+	case <-ctx.Done():
+		slog.Warn("⏱️  Killing Python process", slog.Int("process_id", proc.id), slog.Any("error", ctx.Err()))
+		proc.kill()
+		p.markDead(proc)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", ErrProcessTimeout, ctx.Err())
+		}
+		return nil, ctx.Err()
+	case rt := <-done:
+		if rt.err != nil {
+			// Process may be dead, mark for respawn
+			p.markDead(proc)
+			return nil, rt.err
+		}
+		responseLine = rt.line
 	}
 
 	// Parse response
@@ -178,12 +539,109 @@ func (p *ProcessPool) Execute(request rabbitmq.TranscriptionRequest) (*rabbitmq.
 		return nil, fmt.Errorf("failed to parse response: %w, raw: %s", err, responseLine)
 	}
 
+	proc.mu.Lock()
 	proc.lastUsed = time.Now()
+	proc.mu.Unlock()
+	if !response.Success {
+		return nil, &ErrPythonError{Message: response.ErrorMessage}
+	}
 	return &response, nil
 }
 
-// acquireProcess gets an available process from the pool.
-func (p *ProcessPool) acquireProcess() (*PythonProcess, error) {
+// markDead flags a process for respawn on the next acquire.
+func (p *ProcessPool) markDead(proc *PythonProcess) {
+	proc.mu.Lock()
+	proc.alive = false
+	proc.mu.Unlock()
+}
+
+// acquireProcess gets an available process from the pool. A process
+// respawned for the job inherits traceparent as TRACEPARENT.
+//
+// Pings and respawns run without p.mu, on a process already marked busy,
+// so a slow worker does not hold up the other acquires.
+func (p *ProcessPool) acquireProcess(traceparent string) (*PythonProcess, error) {
+	for {
+		proc, slot, err := p.claimProcess()
+		if err != nil {
+			return nil, err
+		}
+
+		if slot >= 0 {
+			newProc, err := p.respawnClaimed(slot, proc, traceparent)
+			if err != nil {
+				// The slot is in backoff now, or was replaced meanwhile
+				continue
+			}
+			return newProc, nil
+		}
+
+		// The process may have died since it was released
+		if proc.pingTimeout > 0 {
+			if err := proc.Ping(); err != nil {
+				slog.Warn("⚠️  Python process failed ping", slog.Int("process_id", proc.id), slog.Any("error", err))
+				proc.mu.Lock()
+				proc.busy = false
+				proc.alive = false
+				proc.mu.Unlock()
+				continue
+			}
+		}
+		return proc, nil
+	}
+}
+
+// awaitProcess retries acquireProcess each time a process is released, and
+// at least every initialSpawnBackoff for slots leaving backoff, until it
+// gets one, ctx is done or the pool shuts down.
+func (p *ProcessPool) awaitProcess(ctx context.Context, traceparent string) (*PythonProcess, error) {
+	stop := context.AfterFunc(ctx, p.notifyIdle)
+	defer stop()
+
+	for {
+		released := p.releaseCount()
+		proc, err := p.acquireProcess(traceparent)
+		if !errors.Is(err, ErrNoWorkers) {
+			return proc, err
+		}
+
+		select {
+		case <-p.shutdown:
+			return nil, err
+		default:
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %w", err, ctx.Err())
+		}
+		p.waitForRelease(released, initialSpawnBackoff)
+	}
+}
+
+// releaseCount returns how many times notifyIdle has been called.
+func (p *ProcessPool) releaseCount() uint64 {
+	p.idleMu.Lock()
+	defer p.idleMu.Unlock()
+	return p.releases
+}
+
+// waitForRelease blocks until notifyIdle is called after releaseCount
+// returned released, or for at most d.
+func (p *ProcessPool) waitForRelease(released uint64, d time.Duration) {
+	timer := time.AfterFunc(d, p.notifyIdle)
+	defer timer.Stop()
+
+	p.idleMu.Lock()
+	defer p.idleMu.Unlock()
+	for p.releases == released {
+		p.idle.Wait()
+	}
+}
+
+// claimProcess marks a free process busy and returns it with slot -1. When
+// none is free it promotes a spare into a dead slot, or claims a dead slot
+// whose backoff has elapsed and returns the dead process with its slot for
+// the caller to respawn.
+func (p *ProcessPool) claimProcess() (*PythonProcess, int, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -193,39 +651,344 @@ func (p *ProcessPool) acquireProcess() (*PythonProcess, error) {
 		if !proc.busy && proc.alive {
 			proc.busy = true
 			proc.mu.Unlock()
-			return proc, nil
+			return proc, -1, nil
 		}
 		proc.mu.Unlock()
 	}
 
-	// Try to respawn dead processes
+	// Try to respawn dead processes, skipping slots still in backoff and
+	// slots another acquire is respawning
+	now := time.Now()
+	var waiting int
+	var nextRetry time.Time
 	for i, proc := range p.processes {
 		proc.mu.Lock()
-		if !proc.alive {
+		if proc.alive || proc.busy {
 			proc.mu.Unlock()
-			
-			log.Printf("🔄 Respawning Py%d", proc.id)
-			newProc, err := p.spawnProcess(i)
-			if err != nil {
-				log.Printf("[Pool] Failed to respawn worker %d: %v", i, err)
-				continue
+			continue
+		}
+		proc.mu.Unlock()
+
+		if spare := p.promoteSpare(i); spare != nil {
+			spare.mu.Lock()
+			spare.busy = true
+			spare.mu.Unlock()
+			return spare, -1, nil
+		}
+
+		proc.mu.Lock()
+		if now.Before(proc.nextSpawnAt) {
+			if waiting == 0 || proc.nextSpawnAt.Before(nextRetry) {
+				nextRetry = proc.nextSpawnAt
 			}
-			
-			newProc.busy = true
-			p.processes[i] = newProc
-			return newProc, nil
+			waiting++
+			proc.mu.Unlock()
+			continue
 		}
+		proc.busy = true
+		proc.mu.Unlock()
+		return proc, i, nil
+	}
+
+	if waiting > 0 {
+		return nil, -1, fmt.Errorf("%w: %d dead processes in spawn backoff, next retry in %s",
+			ErrNoWorkers, waiting, time.Until(nextRetry).Round(time.Second))
+	}
+	return nil, -1, ErrNoWorkers
+}
+
+// respawnClaimed replaces old, the dead process claimed in slot i, with a
+// new busy process. The spawn runs without p.mu, which is only taken again
+// to install the new process.
+func (p *ProcessPool) respawnClaimed(i int, old *PythonProcess, traceparent string) (*PythonProcess, error) {
+	p.mu.Lock()
+	env := p.pythonEnv
+	p.mu.Unlock()
+	if traceparent != "" {
+		env = append(env[:len(env):len(env)], "TRACEPARENT="+traceparent)
+	}
+
+	newProc, err := p.respawn(i, old, env)
+	old.mu.Lock()
+	old.busy = false
+	old.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-p.shutdown:
+		go stopProcess(newProc)
+		return nil, ErrNoWorkers
+	default:
+	}
+
+	// Resize may have removed the slot while the process was loading
+	if i >= len(p.processes) || p.processes[i] != old {
+		go stopProcess(newProc)
+		return nil, fmt.Errorf("slot %d was replaced during respawn", i)
+	}
+	newProc.busy = true
+	p.processes[i] = newProc
+	return newProc, nil
+}
+
+// respawn starts a replacement for old, the dead process in slot i,
+// recording a failure in the slot's backoff.
+func (p *ProcessPool) respawn(i int, old *PythonProcess, env []string) (*PythonProcess, error) {
+	slog.Info("🔄 Respawning Python process", slog.Int("process_id", i))
+	newProc, err := p.spawnProcess(i, env)
+	if err != nil {
+		backoff, attempts := p.recordSpawnFailure(old)
+		slog.Error("❌ Failed to respawn Python process",
+			slog.Int("process_id", i),
+			slog.Int("attempts", attempts),
+			slog.Duration("retry_in", backoff),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	metrics.ProcessRestarts.Inc()
+	return newProc, nil
+}
+
+// RespawnDead replaces the dead processes whose backoff has elapsed now,
+// instead of waiting for the next acquire, so the model is loaded before
+// the next job arrives.
+func (p *ProcessPool) RespawnDead() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for i, proc := range p.processes {
+		proc.mu.Lock()
+		dead := !proc.alive && !proc.busy && !proc.retired
+		ready := dead && !now.Before(proc.nextSpawnAt)
 		proc.mu.Unlock()
+		if dead && p.promoteSpare(i) != nil {
+			continue
+		}
+		if !ready {
+			continue
+		}
+
+		if newProc, err := p.respawn(i, proc, p.pythonEnv); err == nil {
+			p.processes[i] = newProc
+		}
+	}
+	p.topUpSpares()
+}
+
+// promoteSpare moves a live spare into dead slot i and starts spawning its
+// replacement. It returns nil when no spare is ready. Caller must hold p.mu.
+func (p *ProcessPool) promoteSpare(i int) *PythonProcess {
+	for len(p.spares) > 0 {
+		spare := p.spares[0]
+		p.spares = p.spares[1:]
+
+		spare.mu.Lock()
+		alive := spare.alive
+		spare.spare = false
+		spare.mu.Unlock()
+		if !alive {
+			go stopProcess(spare)
+			continue
+		}
+
+		slog.Info("🔁 Spare Python process promoted",
+			slog.Int("process_id", spare.id),
+			slog.Int("slot", i))
+		p.processes[i] = spare
+		p.topUpSpares()
+		return spare
+	}
+	p.topUpSpares()
+	return nil
+}
+
+// topUpSpares starts spawning spares until the reserve is back at
+// spareCount. Caller must hold p.mu.
+func (p *ProcessPool) topUpSpares() {
+	select {
+	case <-p.shutdown:
+		return
+	default:
 	}
 
-	return nil, fmt.Errorf("no available workers")
+	for len(p.spares)+p.sparesQueued < p.spareCount {
+		p.sparesQueued++
+		go p.spawnSpare(p.nextSpareID, p.pythonEnv)
+		p.nextSpareID++
+	}
+}
+
+// spawnSpare starts a spare process and adds it to the reserve. On failure
+// the next RespawnDead tries again.
+func (p *ProcessPool) spawnSpare(id int, env []string) {
+	proc, err := p.spawnProcess(id, env)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sparesQueued--
+	if err != nil {
+		slog.Error("❌ Failed to spawn spare Python process", slog.Int("process_id", id), slog.Any("error", err))
+		return
+	}
+
+	select {
+	case <-p.shutdown:
+		go stopProcess(proc)
+		return
+	default:
+	}
+
+	// SetPythonEnv ran while the spare was loading
+	if !slices.Equal(env, p.pythonEnv) {
+		go stopProcess(proc)
+		p.topUpSpares()
+		return
+	}
+
+	proc.spare = true
+	p.spares = append(p.spares, proc)
+}
+
+// recordSpawnFailure doubles the respawn backoff of a dead slot, up to
+// maxBackoff, and returns it with the number of consecutive failures.
+func (p *ProcessPool) recordSpawnFailure(proc *PythonProcess) (time.Duration, int) {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	if proc.backoff == 0 {
+		proc.backoff = initialSpawnBackoff
+	} else {
+		proc.backoff *= 2
+	}
+	if p.maxBackoff > 0 && proc.backoff > p.maxBackoff {
+		proc.backoff = p.maxBackoff
+	}
+	proc.spawnAttempts++
+	proc.nextSpawnAt = time.Now().Add(proc.backoff)
+	return proc.backoff, proc.spawnAttempts
 }
 
 // releaseProcess marks a process as available.
 func (p *ProcessPool) releaseProcess(proc *PythonProcess) {
 	proc.mu.Lock()
 	proc.busy = false
+	retired := proc.retired
 	proc.mu.Unlock()
+
+	if retired {
+		stopProcess(proc)
+	}
+	p.notifyIdle()
+}
+
+// notifyIdle wakes WaitForIdle and awaitProcess callers after a process
+// stopped being busy.
+func (p *ProcessPool) notifyIdle() {
+	p.idleMu.Lock()
+	p.releases++
+	p.idle.Broadcast()
+	p.idleMu.Unlock()
+}
+
+// WaitForIdle blocks until no process is busy or ctx is done. Combined with
+// Pool.Pause it guarantees no transcription is running, e.g. before
+// replacing files in the models directory.
+func (p *ProcessPool) WaitForIdle(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, p.notifyIdle)
+	defer stop()
+
+	p.idleMu.Lock()
+	defer p.idleMu.Unlock()
+
+	for {
+		if _, _, busy := p.Counts(); busy == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.idle.Wait()
+	}
+}
+
+// Resize grows or shrinks the pool to n processes.
+// New processes are spawned before they are added; surplus processes are
+// removed from the end of the pool, and busy ones are stopped once released.
+func (p *ProcessPool) Resize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("pool size must be at least 1, got %d", n)
+	}
+
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+
+	p.mu.Lock()
+	old := len(p.processes)
+	env := p.pythonEnv
+	p.mu.Unlock()
+
+	if n > old {
+		spawned := make([]*PythonProcess, 0, n-old)
+		for i := old; i < n; i++ {
+			proc, err := p.spawnProcess(i, env)
+			if err != nil {
+				for _, proc := range spawned {
+					stopProcess(proc)
+				}
+				return fmt.Errorf("failed to spawn process %d: %w", i, err)
+			}
+			spawned = append(spawned, proc)
+		}
+
+		p.mu.Lock()
+		p.processes = append(p.processes, spawned...)
+		p.maxWorkers = n
+		p.mu.Unlock()
+	}
+
+	if n < old {
+		p.mu.Lock()
+		surplus := p.processes[n:]
+		p.processes = p.processes[:n:n]
+		p.maxWorkers = n
+		p.mu.Unlock()
+
+		for _, proc := range surplus {
+			proc.mu.Lock()
+			busy := proc.busy
+			proc.retired = true
+			proc.mu.Unlock()
+
+			if !busy {
+				stopProcess(proc)
+			}
+		}
+	}
+
+	slog.Info("📐 Python pool resized", slog.Int("old", old), slog.Int("new", n))
+	return nil
+}
+
+// stopProcess closes stdin and kills a process that is no longer in the pool.
+func stopProcess(proc *PythonProcess) {
+	proc.mu.Lock()
+	proc.alive = false
+	proc.mu.Unlock()
+
+	if proc.cmd == nil || proc.cmd.Process == nil {
+		return
+	}
+	proc.stdin.Close()
+	proc.kill()
+	proc.cmd.Wait()
 }
 
 // idleCleanupLoop periodically checks for and kills idle processes.
@@ -243,45 +1006,261 @@ func (p *ProcessPool) idleCleanupLoop() {
 	}
 }
 
-// cleanupIdleProcesses kills processes that have been idle too long.
+// cleanupIdleProcesses stops processes that have been idle too long. They
+// get SIGTERM and up to idleGrace to exit before being killed.
 func (p *ProcessPool) cleanupIdleProcesses() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	var idle []*PythonProcess
 
+	p.mu.Lock()
 	for _, proc := range p.processes {
 		proc.mu.Lock()
 		if !proc.busy && proc.alive && time.Since(proc.lastUsed) > p.idleTimeout {
-			log.Printf("💤 Killing idle Py%d", proc.id)
-			proc.cmd.Process.Kill()
 			proc.alive = false
+			idle = append(idle, proc)
 		}
 		proc.mu.Unlock()
 	}
+	p.mu.Unlock()
+
+	for _, proc := range idle {
+		slog.Info("💤 Stopping idle Python process", slog.Int("process_id", proc.id))
+		proc.stdin.Close()
+		go p.terminate(proc, p.idleGrace)
+	}
 }
 
 // Shutdown gracefully shuts down all Python processes.
 func (p *ProcessPool) Shutdown() {
 	close(p.shutdown)
+	p.notifyIdle()
 
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for _, proc := range p.processes {
+	// Terminate in parallel so the grace periods overlap
+	var wg sync.WaitGroup
+	for _, proc := range append(p.processes[:len(p.processes):len(p.processes)], p.spares...) {
 		if proc != nil && proc.cmd != nil && proc.cmd.Process != nil {
 			proc.stdin.Close()
-			proc.cmd.Process.Kill()
-			proc.cmd.Wait()
+			wg.Add(1)
+			go func(proc *PythonProcess) {
+				defer wg.Done()
+				p.terminate(proc, shutdownGracePeriod)
+			}(proc)
+		}
+	}
+	wg.Wait()
+}
+
+// terminate sends SIGTERM to the worker and waits up to grace for it to
+// exit, then kills whatever is left of its process group. Children such as
+// ffmpeg are not signalled until then, so the worker can finish the request
+// in progress. The outcome is counted in the pool stats.
+func (p *ProcessPool) terminate(proc *PythonProcess, grace time.Duration) {
+	exited := make(chan struct{})
+	go func() {
+		proc.cmd.Wait()
+		close(exited)
+	}()
+
+	if err := proc.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		proc.cmd.Process.Kill()
+	}
+
+	select {
+	case <-exited:
+		p.gracefulTerms.Add(1)
+	case <-time.After(grace):
+		slog.Warn("⏱️  Python process ignored SIGTERM, killing it", slog.Int("process_id", proc.id))
+		p.forceKills.Add(1)
+	}
+
+	// Children may outlive the worker itself
+	killProcessGroup(proc.cmd)
+	<-exited
+}
+
+// kill kills the process and every child it started.
+func (proc *PythonProcess) kill() {
+	killProcessGroup(proc.cmd)
+}
+
+// killProcessGroup sends SIGKILL to the process group of cmd, falling back
+// to the process alone.
+func killProcessGroup(cmd *exec.Cmd) {
+	if err := signalProcessGroup(cmd, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// SetPythonEnv replaces the environment used for processes spawned from now on.
+// Running processes keep the environment they were started with; spares
+// have not run any job yet, so they are replaced with the new one.
+func (p *ProcessPool) SetPythonEnv(env []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pythonEnv = append([]string(nil), env...)
+
+	for _, spare := range p.spares {
+		go stopProcess(spare)
+	}
+	p.spares = nil
+	p.topUpSpares()
+}
+
+// HotSwapModel switches the pool to a different Whisper model without downtime.
+//
+// The new WHISPER_MODEL is stored via SetPythonEnv, then each slot is replaced
+// one at a time: a replacement process is spawned and loads the new model while
+// the old one keeps serving jobs, and the swap happens once the old process is
+// idle. The old process is stopped with SIGTERM and killed only after
+// shutdownGracePeriod. If a slot stays busy past the timeout the swap stops
+// there; remaining slots pick up the new model the next time they are
+// respawned.
+func (p *ProcessPool) HotSwapModel(model string, timeout time.Duration) error {
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+
+	p.mu.Lock()
+	env := setEnvValue(p.pythonEnv, "WHISPER_MODEL", model)
+	slots := len(p.processes)
+	p.mu.Unlock()
+
+	p.SetPythonEnv(env)
+	slog.Info("🔁 Hot-swapping model", slog.String("model", model))
+
+	deadline := time.Now().Add(timeout)
+	for i := 0; i < slots; i++ {
+		newProc, err := p.spawnProcess(i, env)
+		if err != nil {
+			return fmt.Errorf("failed to spawn Py%d with model %s: %w", i, model, err)
+		}
+
+		old, err := p.swapWhenIdle(i, newProc, deadline)
+		if err != nil {
+			newProc.stdin.Close()
+			killProcessGroup(newProc.cmd)
+			newProc.cmd.Wait()
+			return err
+		}
+
+		// SIGTERM first, like Shutdown, so the worker and its children can
+		// exit cleanly before the group is killed
+		if old != nil && old.cmd != nil && old.cmd.Process != nil {
+			old.stdin.Close()
+			p.terminate(old, shutdownGracePeriod)
+		}
+	}
+
+	slog.Info("✅ Model swapped", slog.String("model", model))
+	return nil
+}
+
+// swapWhenIdle installs newProc in slot i once the current process there is
+// not busy, returning the replaced process.
+func (p *ProcessPool) swapWhenIdle(i int, newProc *PythonProcess, deadline time.Time) (*PythonProcess, error) {
+	for {
+		p.mu.Lock()
+		old := p.processes[i]
+		old.mu.Lock()
+		if !old.busy {
+			old.alive = false
+			old.mu.Unlock()
+			p.processes[i] = newProc
+			p.mu.Unlock()
+			return old, nil
+		}
+		old.mu.Unlock()
+		p.mu.Unlock()
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for Py%d to become idle", i)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// setEnvValue returns a copy of env with key set to value.
+func setEnvValue(env []string, key, value string) []string {
+	prefix := key + "="
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, prefix) {
+			out = append(out, kv)
 		}
 	}
+	return append(out, prefix+value)
 }
 
 // Stats returns pool statistics.
 func (p *ProcessPool) Stats() map[string]interface{} {
+	total, alive, busy := p.Counts()
+	maxStartup, avgStartup := p.startupDurations()
+
+	return map[string]interface{}{
+		"total":   total,
+		"alive":   alive,
+		"busy":    busy,
+		"idle":    alive - busy,
+		"spares":  p.spareReady(),
+		"devices": p.Devices(),
+
+		"max_startup_ms": maxStartup,
+		"avg_startup_ms": avgStartup,
+
+		"gracefully_terminated": p.gracefulTerms.Load(),
+		"force_killed":          p.forceKills.Load(),
+		"local_fallbacks":       p.localFallbacks.Load(),
+	}
+}
+
+// startupDurations returns the maximum and average startup time, in
+// milliseconds, of the current processes. Slots never started are skipped.
+func (p *ProcessPool) startupDurations() (maxMs, avgMs int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var total, started int64
+	for _, proc := range p.processes {
+		if proc.startupDurationMs == 0 {
+			continue
+		}
+		total += proc.startupDurationMs
+		started++
+		maxMs = max(maxMs, proc.startupDurationMs)
+	}
+	if started == 0 {
+		return 0, 0
+	}
+	return maxMs, total / started
+}
+
+// Devices returns the inference device of each process, indexed by slot.
+func (p *ProcessPool) Devices() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	devices := make([]string, len(p.processes))
+	for i, proc := range p.processes {
+		devices[i] = proc.device
+	}
+	return devices
+}
+
+// spareReady returns the number of spares in the reserve.
+func (p *ProcessPool) spareReady() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.spares)
+}
+
+// Counts returns the number of total, alive and busy processes. Spares are
+// not counted until they are promoted.
+func (p *ProcessPool) Counts() (total, alive, busy int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	alive := 0
-	busy := 0
 	for _, proc := range p.processes {
 		proc.mu.Lock()
 		if proc.alive {
@@ -292,11 +1271,5 @@ func (p *ProcessPool) Stats() map[string]interface{} {
 		}
 		proc.mu.Unlock()
 	}
-
-	return map[string]interface{}{
-		"total":  len(p.processes),
-		"alive":  alive,
-		"busy":   busy,
-		"idle":   alive - busy,
-	}
+	return len(p.processes), alive, busy
 }
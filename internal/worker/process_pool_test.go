@@ -0,0 +1,29 @@
+package worker
+
+import "testing"
+
+func TestDeviceFor(t *testing.T) {
+	env := []string{"WHISPER_MODEL=base", "WHISPER_DEVICE=cpu"}
+	tests := []struct {
+		name    string
+		devices []string
+		id      int
+		env     []string
+		want    string
+	}{
+		{"first device", []string{"cuda:0", "cuda:1", "cuda:2"}, 0, env, "cuda:0"},
+		{"spread over devices", []string{"cuda:0", "cuda:1", "cuda:2"}, 2, env, "cuda:2"},
+		{"wraps around", []string{"cuda:0", "cuda:1", "cuda:2"}, 4, env, "cuda:1"},
+		{"single device", []string{"cuda:1"}, 5, env, "cuda:1"},
+		{"device from env", nil, 3, env, "cpu"},
+		{"no device", nil, 0, []string{"WHISPER_MODEL=base"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ProcessPool{gpuDevices: tt.devices}
+			if got := p.deviceFor(tt.id, tt.env); got != tt.want {
+				t.Errorf("deviceFor(%d) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
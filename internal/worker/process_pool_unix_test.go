@@ -0,0 +1,61 @@
+//go:build unix
+
+package worker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSpawnProcess_ReadyTimeout_KillsProcess(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "pid")
+	script := filepath.Join(dir, "worker.sh")
+	// Loads forever and never prints READY
+	if err := os.WriteFile(script, []byte("echo $$ > "+pidFile+"\nsleep 60\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	const readyTimeout = 200 * time.Millisecond
+	p := &ProcessPool{
+		pythonPath:   "/bin/sh",
+		workerScript: script,
+		readyTimeout: readyTimeout,
+		spawnSlots:   make(chan struct{}, 1),
+		shutdown:     make(chan struct{}),
+	}
+	defer close(p.shutdown)
+
+	start := time.Now()
+	proc, err := p.spawnProcess(0, nil)
+	elapsed := time.Since(start)
+	if err == nil {
+		stopProcess(proc)
+		t.Fatal("spawn succeeded without READY")
+	}
+	if !strings.Contains(err.Error(), "did not send READY") {
+		t.Errorf("error = %v", err)
+	}
+	if elapsed < readyTimeout || elapsed > 10*readyTimeout {
+		t.Errorf("spawn gave up after %s, want about %s", elapsed, readyTimeout)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The process was killed and reaped, so the pid no longer exists
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		t.Errorf("process %d still exists after the timeout: %v", pid, err)
+	}
+}
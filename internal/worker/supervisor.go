@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHeartbeatInterval is used when PoolOptions.HeartbeatInterval is not set.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// WorkerSupervisor tracks the heartbeats of the worker goroutines of a Pool
+// and reports the ones that stopped beating, so the pool never silently
+// runs with fewer workers than numWorkers.
+//
+// An idle worker beats every interval. A worker running a job cannot, so
+// it is only considered lost once the job has run for longer than the job
+// timeout plus the heartbeat timeout; without a job timeout it never is.
+type WorkerSupervisor struct {
+	interval   time.Duration
+	timeout    time.Duration
+	jobTimeout time.Duration
+
+	mu      sync.Mutex
+	workers map[int]*workerBeat // registered workers by ID
+
+	restarts atomic.Int64
+}
+
+// workerBeat is the last sign of life of a worker.
+type workerBeat struct {
+	seen      time.Time
+	busySince time.Time // zero while idle
+}
+
+// NewWorkerSupervisor creates a supervisor expecting a heartbeat every
+// interval. A worker is lost after timeout without one; zero means twice
+// the interval.
+func NewWorkerSupervisor(interval, timeout, jobTimeout time.Duration) *WorkerSupervisor {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	if timeout <= 0 {
+		timeout = 2 * interval
+	}
+	return &WorkerSupervisor{
+		interval:   interval,
+		timeout:    timeout,
+		jobTimeout: jobTimeout,
+		workers:    make(map[int]*workerBeat),
+	}
+}
+
+// register starts tracking worker id.
+func (s *WorkerSupervisor) register(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workers[id] = &workerBeat{seen: time.Now()}
+}
+
+// retire stops tracking worker id after it exited normally.
+func (s *WorkerSupervisor) retire(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.workers, id)
+}
+
+// beat records that worker id is alive and whether it is starting a job.
+// It returns false if the worker was given up on and replaced, in which
+// case it must exit.
+func (s *WorkerSupervisor) beat(id int, busy bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.workers[id]
+	if !ok {
+		return false
+	}
+	w.seen = time.Now()
+	if busy {
+		w.busySince = w.seen
+	} else {
+		w.busySince = time.Time{}
+	}
+	return true
+}
+
+// lost unregisters and returns the workers that missed their heartbeat.
+func (s *WorkerSupervisor) lost(now time.Time) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id, w := range s.workers {
+		stale := now.Sub(w.seen) > s.timeout
+		if !w.busySince.IsZero() {
+			stale = s.jobTimeout > 0 && now.Sub(w.busySince) > s.jobTimeout+s.timeout
+		}
+		if stale {
+			ids = append(ids, id)
+			delete(s.workers, id)
+		}
+	}
+	return ids
+}
+
+// Restarts returns the number of workers replaced so far.
+func (s *WorkerSupervisor) Restarts() int64 {
+	return s.restarts.Load()
+}
+
+// supervise replaces lost workers every heartbeat interval until shutdown.
+func (p *Pool) supervise() {
+	ticker := time.NewTicker(p.supervisor.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.shutdown:
+			return
+		case now := <-ticker.C:
+			for _, id := range p.supervisor.lost(now) {
+				p.supervisor.restarts.Add(1)
+				slog.Error("🚑 Worker missed its heartbeat, starting a replacement", slog.Int("worker_id", id))
+
+				p.mu.Lock()
+				p.startWorker()
+				p.mu.Unlock()
+			}
+		}
+	}
+}
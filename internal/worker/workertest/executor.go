@@ -0,0 +1,79 @@
+// Package workertest provides test doubles for the worker package.
+package workertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"whisper-local/internal/rabbitmq"
+	"whisper-local/internal/worker"
+)
+
+// MockExecutor is a worker.Executor that answers without Python. By default
+// every request succeeds with an empty transcription.
+type MockExecutor struct {
+	// Response is returned when Err is nil; nil means a successful empty response
+	Response *rabbitmq.PythonWorkerResponse
+	// Err is returned instead of a response, e.g. worker.ErrProcessDead or
+	// &worker.ErrPythonError{...}
+	Err error
+	// Delay is waited before answering; a context done first is reported
+	// like ProcessPool does, as worker.ErrProcessTimeout on an expired deadline
+	Delay time.Duration
+	// Func, if set, answers instead of Response and Err
+	Func func(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error)
+
+	mu       sync.Mutex
+	requests []rabbitmq.TranscriptionRequest
+}
+
+var _ worker.Executor = (*MockExecutor)(nil)
+
+// ExecuteWithContext records request and returns the configured answer.
+func (m *MockExecutor) ExecuteWithContext(ctx context.Context, request rabbitmq.TranscriptionRequest) (*rabbitmq.PythonWorkerResponse, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, request)
+	m.mu.Unlock()
+
+	if m.Delay > 0 {
+		timer := time.NewTimer(m.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: %w", worker.ErrProcessTimeout, ctx.Err())
+			}
+			return nil, ctx.Err()
+		}
+	}
+
+	if m.Func != nil {
+		return m.Func(ctx, request)
+	}
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if m.Response != nil {
+		response := *m.Response
+		return &response, nil
+	}
+	return &rabbitmq.PythonWorkerResponse{Success: true}, nil
+}
+
+// Requests returns the requests executed so far, in order.
+func (m *MockExecutor) Requests() []rabbitmq.TranscriptionRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]rabbitmq.TranscriptionRequest(nil), m.requests...)
+}
+
+// Stats returns the number of executed requests.
+func (m *MockExecutor) Stats() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return map[string]interface{}{"calls": len(m.requests)}
+}
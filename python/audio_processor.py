@@ -17,6 +17,8 @@ MAX_FILE_SIZE_MB = int(os.getenv("MAX_FILE_SIZE_MB", "100"))
 MAX_AUDIO_DURATION_SEC = int(os.getenv("MAX_AUDIO_DURATION_SEC", "3600"))
 AUDIO_SAMPLE_RATE = int(os.getenv("AUDIO_SAMPLE_RATE", "16000"))
 TMP_DIR = os.getenv("TMP_DIR", "/tmp/whisper")
+SILENCE_THRESHOLD_DBFS = float(os.getenv("SILENCE_THRESHOLD_DBFS", "-60"))
+SUPPORTED_EXTENSIONS = os.getenv("SUPPORTED_EXTENSIONS", ".opus,.mp3,.wav,.m4a,.ogg,.flac,.aac,.wma")
 
 
 class AudioProcessor:
@@ -25,8 +27,8 @@ class AudioProcessor:
     Converts audio to the format required by Whisper (16kHz, mono WAV).
     """
     
-    # Supported audio formats
-    SUPPORTED_FORMATS = ['.opus', '.mp3', '.wav', '.m4a', '.ogg', '.flac', '.aac', '.wma']
+    # Supported audio formats, normalized by the orchestrator
+    SUPPORTED_FORMATS = [ext.strip() for ext in SUPPORTED_EXTENSIONS.split(',') if ext.strip()]
     
     def __init__(self):
         """Initialize audio processor and ensure tmp directory exists."""
@@ -169,6 +171,19 @@ class AudioProcessor:
         
         return output_path
     
+    def is_silent(self, file_path: str) -> bool:
+        """
+        Check whether an audio file contains no audible sound.
+        
+        Args:
+            file_path: Path to the audio file
+        
+        Returns:
+            True if the loudest sample is below SILENCE_THRESHOLD_DBFS
+        """
+        audio = AudioSegment.from_file(file_path)
+        return len(audio) == 0 or audio.max_dBFS < SILENCE_THRESHOLD_DBFS
+    
     def cleanup(self, file_path: str) -> bool:
         """
         Delete a temporary file.
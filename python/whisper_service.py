@@ -4,11 +4,12 @@ Whisper Service - Handles model loading and audio transcription.
 Standalone module without external app dependencies.
 Configuration loaded from environment variables.
 The model is loaded once when the service starts and kept in memory.
+Per-request override models are loaded on first use and cached.
 """
 import os
 import logging
 from pathlib import Path
-from typing import Optional
+from typing import Dict, Optional
 
 from faster_whisper import WhisperModel
 
@@ -20,9 +21,24 @@ WHISPER_DEVICE = os.getenv("WHISPER_DEVICE", "cpu")
 WHISPER_COMPUTE_TYPE = os.getenv("WHISPER_COMPUTE_TYPE", "int8")
 MODELS_DIR = os.getenv("MODELS_DIR", "./models")
 
+
+def _parse_device(device: str):
+    """
+    Split a device such as "cuda:1" into the device type and index
+    expected by faster-whisper. A bare device uses index 0.
+    """
+    name, _, index = device.partition(":")
+    return name, int(index) if index else 0
+
+
+DEVICE_TYPE, DEVICE_INDEX = _parse_device(WHISPER_DEVICE)
+
 # Global model instance (singleton)
 _model: Optional[WhisperModel] = None
 
+# Override models keyed by name, loaded on demand
+_override_models: Dict[str, WhisperModel] = {}
+
 
 class WhisperService:
     """
@@ -49,7 +65,8 @@ class WhisperService:
             
             _model = WhisperModel(
                 WHISPER_MODEL,
-                device=WHISPER_DEVICE,
+                device=DEVICE_TYPE,
+                device_index=DEVICE_INDEX,
                 compute_type=WHISPER_COMPUTE_TYPE,
                 download_root=MODELS_DIR
             )
@@ -58,11 +75,41 @@ class WhisperService:
             logger.error(f"Failed to load Whisper model: {e}")
             raise RuntimeError(f"Could not initialize Whisper model: {str(e)}")
     
+    def _get_model(self, model_name: Optional[str]) -> WhisperModel:
+        """
+        Return the model to use for a request.
+        
+        Args:
+            model_name: Requested model, or None for the default WHISPER_MODEL
+        
+        Raises:
+            RuntimeError: If the override model cannot be loaded
+        """
+        if not model_name or model_name == WHISPER_MODEL:
+            return self.model
+        
+        if model_name not in _override_models:
+            try:
+                logger.info(f"Loading override {model_name} on {WHISPER_DEVICE}...")
+                _override_models[model_name] = WhisperModel(
+                    model_name,
+                    device=DEVICE_TYPE,
+                    device_index=DEVICE_INDEX,
+                    compute_type=WHISPER_COMPUTE_TYPE,
+                    download_root=MODELS_DIR
+                )
+            except Exception as e:
+                logger.error(f"Failed to load override model {model_name}: {e}")
+                raise RuntimeError(f"Could not load model {model_name}: {str(e)}")
+        
+        return _override_models[model_name]
+    
     def transcribe(
         self,
         audio_path: str,
         language: Optional[str] = None,
-        task: str = "transcribe"
+        task: str = "transcribe",
+        model_name: Optional[str] = None
     ) -> dict:
         """
         Transcribe an audio file to text.
@@ -71,10 +118,12 @@ class WhisperService:
             audio_path: Path to the audio file (should be preprocessed to 16kHz WAV)
             language: Optional language code (e.g., 'es', 'en'). If None, auto-detect
             task: Either 'transcribe' or 'translate' (to English)
+            model_name: Optional model overriding WHISPER_MODEL for this request
         
         Returns:
             Dictionary containing:
                 - text: Full transcription
+                - segments: Timed segments with word-level timestamps
                 - duration: Audio duration in seconds
                 - model: Model name used for transcription
         
@@ -88,29 +137,52 @@ class WhisperService:
         if self.model is None:
             raise RuntimeError("Whisper model not loaded")
         
+        model = self._get_model(model_name)
+        
         try:
             # Transcribe with faster-whisper
-            segments, info = self.model.transcribe(
+            segments, info = model.transcribe(
                 audio_path,
                 language=language,
                 task=task,
                 beam_size=5,
+                word_timestamps=True,
                 vad_filter=True,
                 vad_parameters=dict(
                     min_silence_duration_ms=500
                 )
             )
             
+            # Collect timed segments (the generator is consumed only once)
+            timed_segments = [
+                {
+                    "start": segment.start,
+                    "end": segment.end,
+                    "text": segment.text.strip(),
+                    "words": [
+                        {
+                            "word": word.word.strip(),
+                            "start": word.start,
+                            "end": word.end,
+                            "probability": word.probability
+                        }
+                        for word in (segment.words or [])
+                    ]
+                }
+                for segment in segments
+            ]
+            
             # Concatenate all segments
-            full_text = " ".join(segment.text.strip() for segment in segments)
+            full_text = " ".join(segment["text"] for segment in timed_segments)
             
             # Clean up text (remove extra spaces)
             full_text = " ".join(full_text.split())
             
             return {
                 "text": full_text,
+                "segments": timed_segments,
                 "duration": info.duration,
-                "model": WHISPER_MODEL,
+                "model": model_name or WHISPER_MODEL,
                 "language": info.language,
                 "language_probability": info.language_probability
             }
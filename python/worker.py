@@ -12,6 +12,9 @@ Communication protocol:
 - Startup: prints "READY" to stdout when initialized
 - Request: JSON line on stdin {"audio_file_path": "...", "language": "..."}
 - Response: JSON line on stdout {"success": true/false, ...}
+- Ping: {"ping": true} on stdin is answered with {"pong": true}
+- Tracing: requests may carry "trace_context" (W3C traceparent), logged with errors
+- SIGTERM: exits at once when idle, or after answering the request in progress
 """
 import sys
 import json
@@ -35,10 +38,17 @@ from whisper_service import WhisperService
 # Idle timeout in seconds (also controlled by Go)
 IDLE_TIMEOUT = int(os.getenv("PROCESS_IDLE_TIMEOUT_SEC", "300"))  # 5 minutes
 
+# Skip transcription for audio with no sound
+SKIP_SILENT_FILES = os.getenv("SKIP_SILENT_FILES", "false").lower() == "true"
+
 # Global services (initialized once)
 audio_processor = None
 whisper_service = None
 
+# SIGTERM state: a request in progress is finished before exiting
+busy = False
+stop_requested = False
+
 
 def init_services():
     """Initialize services and load Whisper model."""
@@ -55,24 +65,39 @@ def process_request(request: dict) -> dict:
     Process a single transcription request.
     
     Args:
-        request: Dict with 'audio_file_path' and optional 'language'
+        request: Dict with 'audio_file_path' and optional 'language' and 'model'
     
     Returns:
-        Dict with 'success', 'texto', 'duration', 'model' or 'error_message'
+        Dict with 'success', 'texto', 'segments', 'duration', 'model' or 'error_message'
     """
     processed_wav_path = None
     
     try:
         audio_file_path = request["audio_file_path"]
         language = request.get("language")
+        model_name = request.get("model")
         
         # Step 1: Validate and convert audio to 16kHz WAV
         processed_wav_path = audio_processor.process_audio(audio_file_path)
         
+        # Step 1b: Short-circuit silent audio
+        if SKIP_SILENT_FILES and audio_processor.is_silent(processed_wav_path):
+            duration = audio_processor.get_audio_duration(processed_wav_path)
+            audio_processor.cleanup(processed_wav_path)
+            audio_processor.cleanup(audio_file_path)
+            return {
+                "success": True,
+                "texto": "",
+                "duration": duration,
+                "model": whisper_service.get_model_info()["model"],
+                "is_silent": True
+            }
+        
         # Step 2: Transcribe with Whisper
         result = whisper_service.transcribe(
             audio_path=processed_wav_path,
-            language=language
+            language=language,
+            model_name=model_name
         )
         
         # Step 3: Cleanup temporary files
@@ -82,6 +107,7 @@ def process_request(request: dict) -> dict:
         return {
             "success": True,
             "texto": result["text"],
+            "segments": result["segments"],
             "duration": result["duration"],
             "model": result["model"]
         }
@@ -102,7 +128,7 @@ def process_request(request: dict) -> dict:
         }
         
     except Exception as e:
-        logger.error(f"❌ {type(e).__name__}: {str(e)}")
+        logger.error(f"❌ {type(e).__name__}: {str(e)} [traceparent={request.get('trace_context', '-')}]")
         return {
             "success": False,
             "error_message": f"Processing error: {str(e)}"
@@ -116,6 +142,8 @@ def main_loop():
     Reads JSON requests from stdin, processes them, and writes responses to stdout.
     Uses select() for timeout-based idle detection on Linux.
     """
+    global busy
+    
     while True:
         try:
             # Wait for input with timeout (for idle detection)
@@ -148,12 +176,25 @@ def main_loop():
                 print(json.dumps(response), flush=True)
                 continue
             
+            # Health check from Go, answered without touching audio
+            if request.get("ping"):
+                print(json.dumps({"pong": True}), flush=True)
+                continue
+            
             # Process request
-            response = process_request(request)
+            busy = True
+            try:
+                response = process_request(request)
+            finally:
+                busy = False
             
             # Write response (single JSON line)
             print(json.dumps(response), flush=True)
             
+            if stop_requested:
+                logger.info("🛑 SIGTERM, exiting after request")
+                break
+            
         except KeyboardInterrupt:
             break
         except Exception as e:
@@ -166,7 +207,16 @@ def main_loop():
 
 
 def handle_sigterm(signum, frame):
-    """Handle SIGTERM signal for graceful shutdown."""
+    """
+    Handle SIGTERM signal for graceful shutdown.
+    
+    An idle worker exits immediately; a busy one finishes the current
+    request, writes its response and then exits.
+    """
+    global stop_requested
+    if busy:
+        stop_requested = True
+        return
     sys.exit(0)
 
 